@@ -0,0 +1,94 @@
+package layer1
+
+// This file contains table tests for the following functions:
+// - GuidanceDocument.LoadFile
+// - GuidanceDocument.LoadFiles
+
+// The test data is pulled from ./test-data/
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var tests = []struct {
+	name       string
+	sourcePath string
+	wantErr    bool
+}{
+	{
+		name:       "Bad path",
+		sourcePath: "file://bad-path.yaml",
+		wantErr:    true,
+	},
+	{
+		name:       "Bad YAML",
+		sourcePath: "file://test-data/bad.yaml",
+		wantErr:    true,
+	},
+	{
+		name:       "Bad JSON",
+		sourcePath: "file://test-data/bad.json",
+		wantErr:    true,
+	},
+	{
+		name:       "Good YAML — AIGF",
+		sourcePath: "file://test-data/good-aigf.yaml",
+		wantErr:    false,
+	},
+	{
+		name:       "Good JSON — AIGF Preventative",
+		sourcePath: "file://test-data/good-aigf-prev.json",
+		wantErr:    false,
+	},
+	{
+		name:       "Unrecognized file extension",
+		sourcePath: "file://test-data/unknown.ext",
+		wantErr:    true,
+	},
+}
+
+func Test_LoadFile(t *testing.T) {
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &GuidanceDocument{}
+			err := g.LoadFile(tt.sourcePath)
+			if (err == nil) == tt.wantErr {
+				t.Errorf("GuidanceDocument.LoadFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(g.Categories) == 0 {
+				t.Errorf("GuidanceDocument.LoadFile() did not load any categories")
+			} else if !tt.wantErr && len(g.Categories) > 0 {
+				assert.NotEmpty(t, g.Metadata.Id, "Guidance document ID should not be empty")
+				assert.NotEmpty(t, g.Categories[0].Title, "Category title should not be empty")
+				assert.NotEmpty(t, g.Categories[0].Guidelines, "Category guidelines should not be empty")
+			}
+		})
+	}
+}
+
+func Test_LoadFiles(t *testing.T) {
+	t.Run("Merges multiple files", func(t *testing.T) {
+		g := &GuidanceDocument{}
+		err := g.LoadFiles([]string{
+			"file://test-data/good-aigf.yaml",
+			"file://test-data/good-aigf-prev.json",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "FINOS-AIR", g.Metadata.Id, "Metadata should be taken from the first file")
+		assert.NotEmpty(t, g.FrontMatter)
+		assert.Len(t, g.Categories, 2)
+		assert.Len(t, g.ImportedGuidelines, 1)
+		assert.Len(t, g.ImportedPrinciples, 1)
+	})
+
+	t.Run("Returns error for any bad file", func(t *testing.T) {
+		g := &GuidanceDocument{}
+		err := g.LoadFiles([]string{
+			"file://test-data/good-aigf.yaml",
+			"file://test-data/bad.yaml",
+		})
+		assert.Error(t, err)
+	})
+}
@@ -147,13 +147,46 @@ type GuidelineModifier struct {
 
 	Rationale	*string	`json:"rationale,omitempty" yaml:"rationale,omitempty"`
 
-	GuidelineMappings	[]*Mapping	`json:"guideline-mappings,omitempty" yaml:"guideline-mappings,omitempty"`
+	GuidelineMappings	[]GuidelineMapping	`json:"guideline-mappings,omitempty" yaml:"guideline-mappings,omitempty"`
 
-	PrincipleMappings	[]*Mapping	`json:"principle-mappings,omitempty" yaml:"principle-mappings,omitempty"`
+	PrincipleMappings	[]GuidelineMapping	`json:"principle-mappings,omitempty" yaml:"principle-mappings,omitempty"`
 
 	SeeAlso	[]string	`json:"see-also,omitempty" yaml:"see-also,omitempty"`
 
 	ExternalReferences	[]string	`json:"external-references,omitempty" yaml:"external-references,omitempty"`
+
+	PartModifications	[]PartModifier	`json:"part-modifications,omitempty" yaml:"part-modifications,omitempty"`
+}
+
+// Guideline mappings are the same as Layer 1 mappings, used to replace the mappings of a modified guideline
+type GuidelineMapping struct {
+	ReferenceId	string	`json:"reference-id" yaml:"reference-id"`
+
+	Entries	[]MappingEntry	`json:"entries" yaml:"entries"`
+
+	Remarks	string	`json:"remarks,omitempty" yaml:"remarks,omitempty"`
+}
+
+type MappingEntry struct {
+	ReferenceId	string	`json:"reference-id" yaml:"reference-id"`
+
+	Strength	int64	`json:"strength" yaml:"strength"`
+
+	Remarks	string	`json:"remarks,omitempty" yaml:"remarks,omitempty"`
+}
+
+type PartModifier struct {
+	TargetId	string	`json:"target-id" yaml:"target-id"`
+
+	ModType	ModType	`json:"modification-type" yaml:"modification-type"`
+
+	ModificationRationale	string	`json:"modification-rationale" yaml:"modification-rationale"`
+
+	Title	string	`json:"title,omitempty" yaml:"title,omitempty"`
+
+	Prose	string	`json:"prose" yaml:"prose"`
+
+	Recommendations	[]string	`json:"recommendations,omitempty" yaml:"recommendations,omitempty"`
 }
 
 type ImplementationPlan struct {
@@ -190,18 +223,4 @@ type EvaluationPoint string
 
 type EnforcementMethod string
 
-type PartModifier struct {
-	TargetId	string	`json:"target-id" yaml:"target-id"`
-
-	ModType	ModType	`json:"modification-type" yaml:"modification-type"`
-
-	ModificationRationale	string	`json:"modification-rationale" yaml:"modification-rationale"`
-
-	Title	string	`json:"title,omitempty" yaml:"title,omitempty"`
-
-	Prose	string	`json:"prose" yaml:"prose"`
-
-	Recommendations	[]string	`json:"recommendations,omitempty" yaml:"recommendations,omitempty"`
-}
-
 type Email string
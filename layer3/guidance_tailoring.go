@@ -0,0 +1,223 @@
+package layer3
+
+import (
+	"fmt"
+
+	"github.com/ossf/gemara/layer1"
+)
+
+// EffectiveGuidance applies the guideline modifications in the policy's GuidanceReferences to the referenced
+// Layer 1 Guidance Documents, producing a single tailored GuidanceDocument.
+// Each referenced document must be provided, identified by its Metadata.Id. Excluded guidelines and parts are
+// removed, and all other modifications replace the values of the fields they set. The modifier's rationale is
+// free text and is not applied to the structured Guideline.Rationale.
+// The returned records describe every modification that was applied, along with its rationale.
+func (p *PolicyDocument) EffectiveGuidance(documents []layer1.GuidanceDocument) (layer1.GuidanceDocument, []ModificationRecord, error) {
+	effective := layer1.GuidanceDocument{
+		Metadata: layer1.Metadata{
+			Id:           p.Metadata.Id,
+			Title:        p.Metadata.Title,
+			Description:  p.Metadata.Objective,
+			Author:       p.Metadata.Contacts.Author.Name,
+			Version:      p.Metadata.Version,
+			LastModified: p.Metadata.LastModified,
+		},
+	}
+
+	documentMap := make(map[string]layer1.GuidanceDocument)
+	for _, document := range documents {
+		documentMap[document.Metadata.Id] = document
+	}
+
+	var records []ModificationRecord
+	for _, reference := range p.GuidanceReferences {
+		document, ok := documentMap[reference.ReferenceId]
+		if !ok {
+			return layer1.GuidanceDocument{}, nil, fmt.Errorf("no guidance document provided for guidance reference %s", reference.ReferenceId)
+		}
+		categories, referenceRecords, err := tailorCategories(document.Categories, reference)
+		if err != nil {
+			return layer1.GuidanceDocument{}, nil, fmt.Errorf("error applying modifications to %s: %w", reference.ReferenceId, err)
+		}
+		records = append(records, referenceRecords...)
+
+		effective.Metadata.MappingReferences = append(effective.Metadata.MappingReferences, layer1.MappingReference{
+			Id:          document.Metadata.Id,
+			Title:       document.Metadata.Title,
+			Version:     document.Metadata.Version,
+			Description: document.Metadata.Description,
+		})
+		effective.Metadata.Resources = append(effective.Metadata.Resources, document.Metadata.Resources...)
+		effective.Categories = append(effective.Categories, categories...)
+		effective.ImportedGuidelines = append(effective.ImportedGuidelines, document.ImportedGuidelines...)
+		effective.ImportedPrinciples = append(effective.ImportedPrinciples, document.ImportedPrinciples...)
+	}
+	return effective, records, nil
+}
+
+// tailorCategories returns a copy of the categories with the guideline modifications from a single reference applied.
+func tailorCategories(categories []layer1.Category, reference Mapping) ([]layer1.Category, []ModificationRecord, error) {
+	guidelineMods := make(map[string]GuidelineModifier)
+	for _, mod := range reference.GuidelineModifications {
+		if err := mod.ModType.validate(); err != nil {
+			return nil, nil, fmt.Errorf("guideline modification %s: %w", mod.TargetId, err)
+		}
+		for _, partMod := range mod.PartModifications {
+			if err := partMod.ModType.validate(); err != nil {
+				return nil, nil, fmt.Errorf("part modification %s: %w", partMod.TargetId, err)
+			}
+		}
+		guidelineMods[mod.TargetId] = mod
+	}
+
+	var records []ModificationRecord
+	applied := make(map[string]bool)
+	tailored := make([]layer1.Category, 0, len(categories))
+	for _, category := range categories {
+		guidelines := make([]layer1.Guideline, 0, len(category.Guidelines))
+		for _, guideline := range category.Guidelines {
+			mod, ok := guidelineMods[guideline.Id]
+			if !ok {
+				guidelines = append(guidelines, guideline)
+				continue
+			}
+			applied[mod.TargetId] = true
+			record := newModificationRecord(reference.ReferenceId, mod.TargetId, mod.ModType, mod.ModificationRationale)
+			if mod.ModType == Exclude {
+				records = append(records, record)
+				continue
+			}
+			record.ChangedFields = applyGuidelineModifier(&guideline, mod)
+
+			parts, partRecords, err := tailorParts(guideline.GuidelineParts, mod.PartModifications, reference.ReferenceId)
+			if err != nil {
+				return nil, nil, fmt.Errorf("guideline %s: %w", guideline.Id, err)
+			}
+			if len(mod.PartModifications) > 0 {
+				guideline.GuidelineParts = parts
+				record.ChangedFields = append(record.ChangedFields, "guideline-parts")
+			}
+			records = append(records, record)
+			records = append(records, partRecords...)
+			guidelines = append(guidelines, guideline)
+		}
+		category.Guidelines = guidelines
+		tailored = append(tailored, category)
+	}
+
+	for _, mod := range reference.GuidelineModifications {
+		if !applied[mod.TargetId] {
+			return nil, nil, fmt.Errorf("guideline modification target %s not found", mod.TargetId)
+		}
+	}
+	return tailored, records, nil
+}
+
+// tailorParts returns a copy of the guideline parts with the provided part modifications applied.
+func tailorParts(parts []layer1.Part, mods []PartModifier, referenceId string) ([]layer1.Part, []ModificationRecord, error) {
+	partMods := make(map[string]PartModifier)
+	for _, mod := range mods {
+		partMods[mod.TargetId] = mod
+	}
+
+	var records []ModificationRecord
+	applied := make(map[string]bool)
+	tailored := make([]layer1.Part, 0, len(parts))
+	for _, part := range parts {
+		mod, ok := partMods[part.Id]
+		if !ok {
+			tailored = append(tailored, part)
+			continue
+		}
+		applied[mod.TargetId] = true
+		record := newModificationRecord(referenceId, mod.TargetId, mod.ModType, mod.ModificationRationale)
+		if mod.ModType == Exclude {
+			records = append(records, record)
+			continue
+		}
+		record.ChangedFields = applyPartModifier(&part, mod)
+		records = append(records, record)
+		tailored = append(tailored, part)
+	}
+
+	for _, mod := range mods {
+		if !applied[mod.TargetId] {
+			return nil, nil, fmt.Errorf("part modification target %s not found", mod.TargetId)
+		}
+	}
+	return tailored, records, nil
+}
+
+// applyGuidelineModifier replaces each guideline field that is set on the modifier, returning the names of the changed fields.
+func applyGuidelineModifier(guideline *layer1.Guideline, mod GuidelineModifier) (changed []string) {
+	if mod.Title != "" {
+		guideline.Title = mod.Title
+		changed = append(changed, "title")
+	}
+	if mod.Objective != "" {
+		guideline.Objective = mod.Objective
+		changed = append(changed, "objective")
+	}
+	if len(mod.Recommendations) > 0 {
+		guideline.Recommendations = append([]string(nil), mod.Recommendations...)
+		changed = append(changed, "recommendations")
+	}
+	if mod.BaseGuidelineID != "" {
+		guideline.BaseGuidelineID = mod.BaseGuidelineID
+		changed = append(changed, "base-guideline-id")
+	}
+	if len(mod.GuidelineMappings) > 0 {
+		guideline.GuidelineMappings = toLayer1Mappings(mod.GuidelineMappings)
+		changed = append(changed, "guideline-mappings")
+	}
+	if len(mod.PrincipleMappings) > 0 {
+		guideline.PrincipleMappings = toLayer1Mappings(mod.PrincipleMappings)
+		changed = append(changed, "principle-mappings")
+	}
+	if len(mod.SeeAlso) > 0 {
+		guideline.SeeAlso = append([]string(nil), mod.SeeAlso...)
+		changed = append(changed, "see-also")
+	}
+	if len(mod.ExternalReferences) > 0 {
+		guideline.ExternalReferences = append([]string(nil), mod.ExternalReferences...)
+		changed = append(changed, "external-references")
+	}
+	return changed
+}
+
+// applyPartModifier replaces each part field that is set on the modifier, returning the names of the changed fields.
+func applyPartModifier(part *layer1.Part, mod PartModifier) (changed []string) {
+	if mod.Title != "" {
+		part.Title = mod.Title
+		changed = append(changed, "title")
+	}
+	if mod.Prose != "" {
+		part.Prose = mod.Prose
+		changed = append(changed, "prose")
+	}
+	if len(mod.Recommendations) > 0 {
+		part.Recommendations = append([]string(nil), mod.Recommendations...)
+		changed = append(changed, "recommendations")
+	}
+	return changed
+}
+
+func toLayer1Mappings(mappings []GuidelineMapping) []layer1.Mapping {
+	converted := make([]layer1.Mapping, 0, len(mappings))
+	for _, mapping := range mappings {
+		entries := make([]layer1.MappingEntry, 0, len(mapping.Entries))
+		for _, entry := range mapping.Entries {
+			entries = append(entries, layer1.MappingEntry{
+				ReferenceId: entry.ReferenceId,
+				Strength:    entry.Strength,
+				Remarks:     entry.Remarks,
+			})
+		}
+		converted = append(converted, layer1.Mapping{
+			ReferenceId: mapping.ReferenceId,
+			Entries:     entries,
+			Remarks:     mapping.Remarks,
+		})
+	}
+	return converted
+}
@@ -0,0 +1,188 @@
+package layer3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ossf/gemara/layer1"
+)
+
+func testGuidance() layer1.GuidanceDocument {
+	return layer1.GuidanceDocument{
+		Metadata: layer1.Metadata{
+			Id:      "FINOS-AIR",
+			Title:   "AI Governance Framework",
+			Version: "0.1.0",
+		},
+		Categories: []layer1.Category{
+			{
+				Id:    "DET",
+				Title: "Detective",
+				Guidelines: []layer1.Guideline{
+					{
+						Id:        "AIR-DET-011",
+						Title:     "Human Feedback Loop for AI Systems",
+						Objective: "Collect and act upon human feedback.",
+						GuidelineParts: []layer1.Part{
+							{
+								Id:    "AIR-DET-011.1",
+								Title: "Designing the Feedback Mechanism",
+								Prose: "Implementing an effective human feedback loop involves careful design of the mechanism.",
+							},
+							{
+								Id:    "AIR-DET-011.2",
+								Title: "Types of Feedback and Collection Methods",
+								Prose: "Implementing an effective human feedback loop involves clear collection processes.",
+							},
+						},
+					},
+					{
+						Id:    "AIR-DET-015",
+						Title: "AI System Observability",
+					},
+				},
+			},
+		},
+	}
+}
+
+func testGuidancePolicy(mods []GuidelineModifier) PolicyDocument {
+	return PolicyDocument{
+		Metadata: Metadata{
+			Id:    "org-policy",
+			Title: "Organization Policy",
+			Contacts: Contacts{
+				Author: Contact{Name: "Security Team Lead"},
+			},
+		},
+		GuidanceReferences: []Mapping{
+			{
+				ReferenceId:            "FINOS-AIR",
+				GuidelineModifications: mods,
+			},
+		},
+	}
+}
+
+func TestEffectiveGuidance(t *testing.T) {
+	policy := testGuidancePolicy([]GuidelineModifier{
+		{
+			TargetId:              "AIR-DET-015",
+			ModType:               Exclude,
+			ModificationRationale: "Observability is covered by the platform team",
+		},
+		{
+			TargetId:              "AIR-DET-011",
+			ModType:               IncreaseStrictness,
+			ModificationRationale: "Feedback must be reviewed weekly",
+			Title:                 "Weekly Human Feedback Review",
+			Recommendations:       []string{"Review collected feedback every week."},
+			GuidelineMappings: []GuidelineMapping{
+				{
+					ReferenceId: "NIST-800-53",
+					Entries:     []MappingEntry{{ReferenceId: "CA-7", Strength: 6}},
+				},
+			},
+			PartModifications: []PartModifier{
+				{
+					TargetId:              "AIR-DET-011.2",
+					ModType:               Exclude,
+					ModificationRationale: "Collection methods are defined elsewhere",
+				},
+				{
+					TargetId:              "AIR-DET-011.1",
+					ModType:               Clarify,
+					ModificationRationale: "Name the feedback tooling",
+					Prose:                 "Feedback MUST be collected through the approved feedback service.",
+				},
+			},
+		},
+	})
+	source := testGuidance()
+
+	guidance, records, err := policy.EffectiveGuidance([]layer1.GuidanceDocument{source})
+	require.NoError(t, err)
+
+	assert.Equal(t, "org-policy", guidance.Metadata.Id)
+	assert.Equal(t, "Security Team Lead", guidance.Metadata.Author)
+	require.Len(t, guidance.Metadata.MappingReferences, 1)
+	assert.Equal(t, "FINOS-AIR", guidance.Metadata.MappingReferences[0].Id)
+
+	require.Len(t, guidance.Categories, 1)
+	guidelines := guidance.Categories[0].Guidelines
+	require.Len(t, guidelines, 1, "excluded guideline should be removed")
+	guideline := guidelines[0]
+	assert.Equal(t, "Weekly Human Feedback Review", guideline.Title)
+	assert.Equal(t, "Collect and act upon human feedback.", guideline.Objective, "unset fields should not be modified")
+	assert.Equal(t, []string{"Review collected feedback every week."}, guideline.Recommendations)
+	require.Len(t, guideline.GuidelineMappings, 1)
+	assert.Equal(t, "CA-7", guideline.GuidelineMappings[0].Entries[0].ReferenceId)
+
+	require.Len(t, guideline.GuidelineParts, 1, "excluded part should be removed")
+	assert.Equal(t, "Feedback MUST be collected through the approved feedback service.", guideline.GuidelineParts[0].Prose)
+	assert.Equal(t, "Designing the Feedback Mechanism", guideline.GuidelineParts[0].Title)
+
+	assert.Len(t, source.Categories[0].Guidelines, 2, "source document should not be modified")
+	assert.Len(t, source.Categories[0].Guidelines[0].GuidelineParts, 2, "source document should not be modified")
+
+	require.Len(t, records, 4)
+	for _, record := range records {
+		assert.Equal(t, "FINOS-AIR", record.ReferenceId)
+		assert.NotEmpty(t, record.ModificationRationale)
+		switch record.TargetId {
+		case "AIR-DET-011":
+			assert.Equal(t, []string{"title", "recommendations", "guideline-mappings", "guideline-parts"}, record.ChangedFields)
+		case "AIR-DET-011.1":
+			assert.Equal(t, []string{"prose"}, record.ChangedFields)
+		default:
+			assert.Equal(t, Exclude, record.ModType)
+		}
+	}
+}
+
+func TestEffectiveGuidance_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    PolicyDocument
+		documents []layer1.GuidanceDocument
+	}{
+		{
+			name:      "Missing referenced guidance document",
+			policy:    testGuidancePolicy(nil),
+			documents: nil,
+		},
+		{
+			name: "Unsupported modification type",
+			policy: testGuidancePolicy([]GuidelineModifier{
+				{TargetId: "AIR-DET-011", ModType: "enhancement"},
+			}),
+			documents: []layer1.GuidanceDocument{testGuidance()},
+		},
+		{
+			name: "Guideline modification target not found",
+			policy: testGuidancePolicy([]GuidelineModifier{
+				{TargetId: "AIR-DET-999", ModType: Clarify},
+			}),
+			documents: []layer1.GuidanceDocument{testGuidance()},
+		},
+		{
+			name: "Part modification target not found",
+			policy: testGuidancePolicy([]GuidelineModifier{
+				{
+					TargetId:          "AIR-DET-011",
+					ModType:           Clarify,
+					PartModifications: []PartModifier{{TargetId: "AIR-DET-011.9", ModType: Clarify}},
+				},
+			}),
+			documents: []layer1.GuidanceDocument{testGuidance()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := tt.policy.EffectiveGuidance(tt.documents)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,387 @@
+package layer3
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/defenseunicorns/go-oscal/src/pkg/uuid"
+	oscal "github.com/defenseunicorns/go-oscal/src/types/oscal-1-1-3"
+
+	oscalUtils "github.com/ossf/gemara/internal/oscal"
+	"github.com/ossf/gemara/layer2"
+)
+
+type generateOpts struct {
+	version string
+	imports map[string]string
+}
+
+func (g *generateOpts) complete(policy PolicyDocument) {
+	if g.version == "" {
+		g.version = policy.Metadata.Version
+	}
+	if g.imports == nil {
+		g.imports = make(map[string]string)
+		for _, mappingRef := range policy.Metadata.MappingReferences {
+			g.imports[mappingRef.Id] = mappingRef.Url
+		}
+	}
+}
+
+// GenerateOption defines an option to tune the behavior of the OSCAL
+// generation methods for Layer 3.
+type GenerateOption func(opts *generateOpts)
+
+// WithVersion is a GenerateOption that sets the version of the OSCAL Document. If set,
+// this will be used instead of the version in PolicyDocument.
+func WithVersion(version string) GenerateOption {
+	return func(opts *generateOpts) {
+		opts.version = version
+	}
+}
+
+// WithOSCALImports is a GenerateOption that provides the `href` to the OSCAL documents for each
+// control and guidance reference by reference ID. If unset, the URL of the mapping reference will be used.
+func WithOSCALImports(imports map[string]string) GenerateOption {
+	return func(opts *generateOpts) {
+		opts.imports = imports
+	}
+}
+
+// ToOSCALProfile creates an OSCAL Profile that tailors the documents referenced by the PolicyDocument.
+// Each referenced Layer 2 Catalog must be provided, identified by its Metadata.Id, so that the profile
+// can include every control that is not excluded and alter the assessment requirements of each control.
+//
+// The function automatically:
+//   - Creates an import for each control reference, including the controls that are not excluded
+//   - Creates an import for each guidance reference, including all guidelines except those that are excluded
+//   - Removes excluded assessment requirements from their controls
+//   - Creates an alteration for each clarify or strictness modification, with the ModificationRationale
+//     carried as the remarks of a "modification-type" property
+func (p *PolicyDocument) ToOSCALProfile(catalogs []layer2.Catalog, opts ...GenerateOption) (oscal.Profile, error) {
+	options := generateOpts{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	options.complete(*p)
+
+	catalogMap := make(map[string]layer2.Catalog)
+	for _, catalog := range catalogs {
+		catalogMap[catalog.Metadata.Id] = catalog
+	}
+
+	var imports []oscal.Import
+	var alters []oscal.Alteration
+	for _, reference := range p.ControlReferences {
+		href, ok := options.imports[reference.ReferenceId]
+		if !ok || href == "" {
+			return oscal.Profile{}, fmt.Errorf("no OSCAL import provided for control reference %s", reference.ReferenceId)
+		}
+		catalog, ok := catalogMap[reference.ReferenceId]
+		if !ok {
+			return oscal.Profile{}, fmt.Errorf("no catalog provided for control reference %s", reference.ReferenceId)
+		}
+		imp, referenceAlters, err := controlReferenceToImport(reference, catalog, href)
+		if err != nil {
+			return oscal.Profile{}, fmt.Errorf("error converting modifications to %s: %w", reference.ReferenceId, err)
+		}
+		imports = append(imports, imp)
+		alters = append(alters, referenceAlters...)
+	}
+
+	for _, reference := range p.GuidanceReferences {
+		href, ok := options.imports[reference.ReferenceId]
+		if !ok || href == "" {
+			return oscal.Profile{}, fmt.Errorf("no OSCAL import provided for guidance reference %s", reference.ReferenceId)
+		}
+		imp, referenceAlters, err := guidanceReferenceToImport(reference, href)
+		if err != nil {
+			return oscal.Profile{}, fmt.Errorf("error converting modifications to %s: %w", reference.ReferenceId, err)
+		}
+		imports = append(imports, imp)
+		alters = append(alters, referenceAlters...)
+	}
+
+	profile := oscal.Profile{
+		UUID:     uuid.NewUUID(),
+		Imports:  imports,
+		Metadata: createMetadata(p, options),
+	}
+	if len(alters) > 0 {
+		profile.Modify = &oscal.Modify{Alters: &alters}
+	}
+	return profile, nil
+}
+
+// controlReferenceToImport creates an import for a control reference, including every control in the catalog that
+// is not excluded, along with the alterations for the reference's control and assessment requirement modifications.
+func controlReferenceToImport(reference Mapping, catalog layer2.Catalog, href string) (oscal.Import, []oscal.Alteration, error) {
+	controls := make(map[string]layer2.Control)
+	requirementControls := make(map[string]string)
+	requirements := make(map[string]layer2.AssessmentRequirement)
+	var controlIds []string
+	for _, family := range catalog.ControlFamilies {
+		for _, control := range family.Controls {
+			controls[control.Id] = control
+			controlIds = append(controlIds, control.Id)
+			for _, requirement := range control.AssessmentRequirements {
+				requirementControls[requirement.Id] = control.Id
+				requirements[requirement.Id] = requirement
+			}
+		}
+	}
+
+	excluded := make(map[string]bool)
+	alterMap := make(map[string]*oscal.Alteration)
+	var alterOrder []string
+	alterFor := func(controlId string) *oscal.Alteration {
+		alter, ok := alterMap[controlId]
+		if !ok {
+			alter = &oscal.Alteration{ControlId: controlId}
+			alterMap[controlId] = alter
+			alterOrder = append(alterOrder, controlId)
+		}
+		return alter
+	}
+
+	for _, mod := range reference.ControlModifications {
+		if err := mod.ModType.validate(); err != nil {
+			return oscal.Import{}, nil, fmt.Errorf("control modification %s: %w", mod.TargetId, err)
+		}
+		if _, ok := controls[mod.TargetId]; !ok {
+			return oscal.Import{}, nil, fmt.Errorf("control modification target %s not found", mod.TargetId)
+		}
+		if mod.ModType == Exclude {
+			excluded[mod.TargetId] = true
+			continue
+		}
+		addition := oscal.Addition{
+			Position: "ending",
+			Title:    mod.Title,
+			Props:    &[]oscal.Property{modificationProperty(mod.ModType, mod.ModificationRationale)},
+		}
+		if mod.Objective != "" {
+			addition.Parts = &[]oscal.Part{
+				{
+					Name:  "assessment-objective",
+					ID:    fmt.Sprintf("%s_obj", mod.TargetId),
+					Prose: mod.Objective,
+				},
+			}
+		}
+		alter := alterFor(mod.TargetId)
+		alter.Adds = appendAddition(alter.Adds, addition)
+	}
+
+	for _, mod := range reference.AssessmentRequirementModifications {
+		if err := mod.ModType.validate(); err != nil {
+			return oscal.Import{}, nil, fmt.Errorf("assessment requirement modification %s: %w", mod.TargetId, err)
+		}
+		controlId, ok := requirementControls[mod.TargetId]
+		if !ok {
+			return oscal.Import{}, nil, fmt.Errorf("assessment requirement modification target %s not found", mod.TargetId)
+		}
+		if excluded[controlId] {
+			continue
+		}
+		// The requirement part is replaced, so that the modified text takes the place of the original
+		alter := alterFor(controlId)
+		alter.Removes = appendRemoval(alter.Removes, oscal.Removal{ById: mod.TargetId})
+		if mod.ModType == Exclude {
+			continue
+		}
+		alter.Adds = appendAddition(alter.Adds, oscal.Addition{
+			Position: "ending",
+			Parts:    &[]oscal.Part{requirementPart(controlId, requirements[mod.TargetId], mod)},
+		})
+	}
+
+	var withIds []string
+	for _, controlId := range controlIds {
+		if !excluded[controlId] {
+			withIds = append(withIds, controlId)
+		}
+	}
+	imp := oscal.Import{
+		Href:            href,
+		IncludeControls: &[]oscal.SelectControlById{{WithIds: &withIds}},
+	}
+
+	var alters []oscal.Alteration
+	for _, controlId := range alterOrder {
+		if !excluded[controlId] {
+			alters = append(alters, *alterMap[controlId])
+		}
+	}
+	return imp, alters, nil
+}
+
+// requirementPart creates the part for a modified assessment requirement, in the same form as layer2 Catalog.ToOSCAL.
+// Fields that are not set by the modification keep the values from the catalog.
+func requirementPart(controlId string, requirement layer2.AssessmentRequirement, mod AssessmentRequirementModifier) oscal.Part {
+	text := requirement.Text
+	if mod.Text != "" {
+		text = mod.Text
+	}
+	recommendation := requirement.Recommendation
+	if mod.Recommendation != "" {
+		recommendation = mod.Recommendation
+	}
+	part := oscal.Part{
+		Class: controlId,
+		ID:    requirement.Id,
+		Name:  requirement.Id,
+		Prose: text,
+		Props: &[]oscal.Property{modificationProperty(mod.ModType, mod.ModificationRationale)},
+	}
+	if recommendation != "" {
+		part.Parts = &[]oscal.Part{
+			{
+				ID:    requirement.Id + ".R",
+				Name:  "recommendation",
+				Ns:    oscalUtils.GemaraNamespace,
+				Prose: recommendation,
+			},
+		}
+	}
+	return part
+}
+
+// guidanceReferenceToImport creates an import for a guidance reference, including every guideline that is not
+// excluded, along with the alterations for the reference's guideline modifications.
+func guidanceReferenceToImport(reference Mapping, href string) (oscal.Import, []oscal.Alteration, error) {
+	var excludedIds []string
+	var alters []oscal.Alteration
+	for _, mod := range reference.GuidelineModifications {
+		if err := mod.ModType.validate(); err != nil {
+			return oscal.Import{}, nil, fmt.Errorf("guideline modification %s: %w", mod.TargetId, err)
+		}
+		controlId := oscalUtils.NormalizeControl(mod.TargetId, false)
+		if mod.ModType == Exclude {
+			excludedIds = append(excludedIds, controlId)
+			continue
+		}
+
+		alter := oscal.Alteration{ControlId: controlId}
+		addition := oscal.Addition{
+			Position: "ending",
+			Title:    mod.Title,
+			Props:    &[]oscal.Property{modificationProperty(mod.ModType, mod.ModificationRationale)},
+		}
+		// Parts are replaced, using the IDs created by layer1 GuidanceDocument.ToOSCALCatalog
+		var parts []oscal.Part
+		if mod.Objective != "" {
+			objectiveId := fmt.Sprintf("%s_obj", controlId)
+			alter.Removes = appendRemoval(alter.Removes, oscal.Removal{ById: objectiveId})
+			parts = append(parts, oscal.Part{Name: "assessment-objective", ID: objectiveId, Prose: mod.Objective})
+		}
+		if len(mod.Recommendations) > 0 {
+			guidanceId := fmt.Sprintf("%s_gdn", controlId)
+			alter.Removes = appendRemoval(alter.Removes, oscal.Removal{ById: guidanceId})
+			parts = append(parts, oscal.Part{Name: "guidance", ID: guidanceId, Prose: strings.Join(mod.Recommendations, " ")})
+		}
+		addition.Parts = oscalUtils.NilIfEmpty(parts)
+		alter.Adds = appendAddition(alter.Adds, addition)
+
+		// Modified guideline parts replace the statement items, and excluded parts are only removed
+		var items []oscal.Part
+		for _, partMod := range mod.PartModifications {
+			if err := partMod.ModType.validate(); err != nil {
+				return oscal.Import{}, nil, fmt.Errorf("part modification %s: %w", partMod.TargetId, err)
+			}
+			itemId := fmt.Sprintf("%s_smt.%s", controlId, oscalUtils.NormalizeControl(partMod.TargetId, true))
+			alter.Removes = appendRemoval(alter.Removes, oscal.Removal{ById: itemId})
+			if partMod.ModType == Exclude {
+				continue
+			}
+			item := oscal.Part{
+				Name:  "item",
+				ID:    itemId,
+				Title: partMod.Title,
+				Prose: partMod.Prose,
+				Props: &[]oscal.Property{modificationProperty(partMod.ModType, partMod.ModificationRationale)},
+			}
+			if len(partMod.Recommendations) > 0 {
+				item.Parts = &[]oscal.Part{{Name: "guidance", ID: fmt.Sprintf("%s_gdn", itemId), Prose: strings.Join(partMod.Recommendations, " ")}}
+			}
+			items = append(items, item)
+		}
+		if len(items) > 0 {
+			alter.Adds = appendAddition(alter.Adds, oscal.Addition{
+				ById:     fmt.Sprintf("%s_smt", controlId),
+				Position: "ending",
+				Parts:    &items,
+			})
+		}
+		alters = append(alters, alter)
+	}
+
+	imp := oscal.Import{
+		Href:       href,
+		IncludeAll: &oscal.IncludeAll{},
+	}
+	if len(excludedIds) > 0 {
+		imp.ExcludeControls = &[]oscal.SelectControlById{{WithIds: &excludedIds}}
+	}
+	return imp, alters, nil
+}
+
+// modificationProperty records the type of a modification, with the rationale for it as remarks.
+func modificationProperty(modType ModType, rationale string) oscal.Property {
+	return oscal.Property{
+		Name:    "modification-type",
+		Ns:      oscalUtils.GemaraNamespace,
+		Value:   string(modType),
+		Remarks: rationale,
+	}
+}
+
+func appendAddition(additions *[]oscal.Addition, addition oscal.Addition) *[]oscal.Addition {
+	if additions == nil {
+		return &[]oscal.Addition{addition}
+	}
+	updated := append(*additions, addition)
+	return &updated
+}
+
+func appendRemoval(removals *[]oscal.Removal, removal oscal.Removal) *[]oscal.Removal {
+	if removals == nil {
+		return &[]oscal.Removal{removal}
+	}
+	updated := append(*removals, removal)
+	return &updated
+}
+
+func createMetadata(policy *PolicyDocument, opts generateOpts) oscal.Metadata {
+	fallbackTime := time.Now()
+	metadata := oscal.Metadata{
+		Title:        policy.Metadata.Title,
+		OscalVersion: oscalUtils.OSCALVersion,
+		Version:      opts.version,
+		LastModified: oscalUtils.GetTimeWithFallback(policy.Metadata.LastModified, fallbackTime),
+		Remarks:      policy.Metadata.Objective,
+	}
+
+	authorRole := oscal.Role{
+		ID:          "author",
+		Description: "Author and owner of the document",
+		Title:       "Author",
+	}
+
+	author := oscal.Party{
+		UUID: uuid.NewUUID(),
+		Type: "person",
+		Name: policy.Contacts.Author.Name,
+	}
+
+	responsibleParty := oscal.ResponsibleParty{
+		PartyUuids: []string{author.UUID},
+		RoleId:     authorRole.ID,
+	}
+
+	metadata.Parties = &[]oscal.Party{author}
+	metadata.Roles = &[]oscal.Role{authorRole}
+	metadata.ResponsibleParties = &[]oscal.ResponsibleParty{responsibleParty}
+	return metadata
+}
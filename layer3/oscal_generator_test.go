@@ -0,0 +1,198 @@
+package layer3
+
+import (
+	"testing"
+
+	oscal "github.com/defenseunicorns/go-oscal/src/types/oscal-1-1-3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	oscalUtils "github.com/ossf/gemara/internal/oscal"
+	"github.com/ossf/gemara/layer2"
+)
+
+func TestToOSCALProfile(t *testing.T) {
+	policy := testPolicy(
+		[]ControlModifier{
+			{
+				TargetId:              "OSPS-AC-04",
+				ModType:               Exclude,
+				ModificationRationale: "CI is managed centrally",
+			},
+			{
+				TargetId:              "OSPS-AC-01",
+				ModType:               Clarify,
+				ModificationRationale: "Align with corporate terminology",
+				Title:                 "Two-factor authentication",
+			},
+		},
+		[]AssessmentRequirementModifier{
+			{
+				TargetId:              "OSPS-AC-03.02",
+				ModType:               Exclude,
+				ModificationRationale: "Branch deletion is disabled organization-wide",
+			},
+			{
+				TargetId:              "OSPS-AC-03.01",
+				ModType:               IncreaseStrictness,
+				ModificationRationale: "Require signed commits as well",
+				Text:                  "Direct commits to the primary branch MUST be prevented and commits MUST be signed.",
+			},
+		},
+	)
+	policy.GuidanceReferences = []Mapping{
+		{
+			ReferenceId: "FINOS-AIR",
+			GuidelineModifications: []GuidelineModifier{
+				{
+					TargetId:              "AIR-DET-011",
+					ModType:               Exclude,
+					ModificationRationale: "Not applicable to internal models",
+				},
+				{
+					TargetId:              "AIR-DET-012",
+					ModType:               Clarify,
+					ModificationRationale: "Use internal terminology",
+					Objective:             "Monitor internal models for drift.",
+					PartModifications: []PartModifier{
+						{
+							TargetId:              "AIR-DET-012.1",
+							ModType:               IncreaseStrictness,
+							ModificationRationale: "Drift must be reviewed weekly",
+							Prose:                 "Review model drift weekly.",
+							Recommendations:       []string{"Automate the weekly review."},
+						},
+						{
+							TargetId:              "AIR-DET-012.2",
+							ModType:               Exclude,
+							ModificationRationale: "Models are not retrained",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	imports := map[string]string{
+		"OSPS-B":    "https://example.com/osps-b.json",
+		"FINOS-AIR": "https://example.com/finos-air.json",
+	}
+	profile, err := policy.ToOSCALProfile([]layer2.Catalog{testCatalog()}, WithOSCALImports(imports))
+	require.NoError(t, err)
+	require.NoError(t, oscalUtils.Validate(oscal.OscalModels{Profile: &profile}))
+
+	assert.Equal(t, "Organization Policy", profile.Metadata.Title)
+	assert.Equal(t, "1.0.0", profile.Metadata.Version)
+
+	require.Len(t, profile.Imports, 2)
+	controlImport := profile.Imports[0]
+	assert.Equal(t, "https://example.com/osps-b.json", controlImport.Href)
+	require.NotNil(t, controlImport.IncludeControls)
+	assert.Equal(t, []string{"OSPS-AC-01", "OSPS-AC-03"}, *(*controlImport.IncludeControls)[0].WithIds)
+
+	guidanceImport := profile.Imports[1]
+	assert.Equal(t, "https://example.com/finos-air.json", guidanceImport.Href)
+	assert.NotNil(t, guidanceImport.IncludeAll)
+	require.NotNil(t, guidanceImport.ExcludeControls)
+	assert.Equal(t, []string{"air-det-011"}, *(*guidanceImport.ExcludeControls)[0].WithIds)
+
+	require.NotNil(t, profile.Modify)
+	alters := *profile.Modify.Alters
+	require.Len(t, alters, 3)
+
+	assert.Equal(t, "OSPS-AC-01", alters[0].ControlId)
+	require.NotNil(t, alters[0].Adds)
+	clarify := (*alters[0].Adds)[0]
+	assert.Equal(t, "Two-factor authentication", clarify.Title)
+	assert.Equal(t, "Align with corporate terminology", (*clarify.Props)[0].Remarks)
+	assert.Equal(t, string(Clarify), (*clarify.Props)[0].Value)
+
+	assert.Equal(t, "OSPS-AC-03", alters[1].ControlId)
+	require.NotNil(t, alters[1].Removes)
+	assert.Equal(t, []oscal.Removal{{ById: "OSPS-AC-03.02"}, {ById: "OSPS-AC-03.01"}}, *alters[1].Removes)
+	require.Len(t, *alters[1].Adds, 1)
+	requirement := (*(*alters[1].Adds)[0].Parts)[0]
+	assert.Equal(t, "OSPS-AC-03.01", requirement.ID)
+	assert.Equal(t, "Direct commits to the primary branch MUST be prevented and commits MUST be signed.", requirement.Prose)
+	assert.Equal(t, "Require signed commits as well", (*requirement.Props)[0].Remarks)
+
+	assert.Equal(t, "air-det-012", alters[2].ControlId)
+	assert.Equal(t, []oscal.Removal{
+		{ById: "air-det-012_obj"},
+		{ById: "air-det-012_smt.1"},
+		{ById: "air-det-012_smt.2"},
+	}, *alters[2].Removes)
+	require.Len(t, *alters[2].Adds, 2)
+	objective := (*(*alters[2].Adds)[0].Parts)[0]
+	assert.Equal(t, "Monitor internal models for drift.", objective.Prose)
+
+	partAddition := (*alters[2].Adds)[1]
+	assert.Equal(t, "air-det-012_smt", partAddition.ById)
+	require.Len(t, *partAddition.Parts, 1)
+	item := (*partAddition.Parts)[0]
+	assert.Equal(t, "air-det-012_smt.1", item.ID)
+	assert.Equal(t, "Review model drift weekly.", item.Prose)
+	assert.Equal(t, "Drift must be reviewed weekly", (*item.Props)[0].Remarks)
+	assert.Equal(t, "air-det-012_smt.1_gdn", (*item.Parts)[0].ID)
+}
+
+func TestToOSCALProfileErrors(t *testing.T) {
+	imports := map[string]string{"OSPS-B": "https://example.com/osps-b.json"}
+	tests := []struct {
+		name     string
+		policy   PolicyDocument
+		catalogs []layer2.Catalog
+		opts     []GenerateOption
+	}{
+		{
+			name:     "missing catalog",
+			policy:   testPolicy(nil, nil),
+			catalogs: nil,
+			opts:     []GenerateOption{WithOSCALImports(imports)},
+		},
+		{
+			name:     "missing import",
+			policy:   testPolicy(nil, nil),
+			catalogs: []layer2.Catalog{testCatalog()},
+		},
+		{
+			name: "unknown control",
+			policy: testPolicy([]ControlModifier{
+				{TargetId: "OSPS-AC-99", ModType: Clarify},
+			}, nil),
+			catalogs: []layer2.Catalog{testCatalog()},
+			opts:     []GenerateOption{WithOSCALImports(imports)},
+		},
+		{
+			name: "invalid part modification type",
+			policy: func() PolicyDocument {
+				policy := testPolicy(nil, nil)
+				policy.GuidanceReferences = []Mapping{{
+					ReferenceId: "OSPS-B",
+					GuidelineModifications: []GuidelineModifier{{
+						TargetId:          "AIR-DET-012",
+						ModType:           Clarify,
+						PartModifications: []PartModifier{{TargetId: "AIR-DET-012.1", ModType: "enhancement"}},
+					}},
+				}}
+				return policy
+			}(),
+			catalogs: []layer2.Catalog{testCatalog()},
+			opts:     []GenerateOption{WithOSCALImports(imports)},
+		},
+		{
+			name: "invalid modification type",
+			policy: testPolicy(nil, []AssessmentRequirementModifier{
+				{TargetId: "OSPS-AC-01.01", ModType: "enhancement"},
+			}),
+			catalogs: []layer2.Catalog{testCatalog()},
+			opts:     []GenerateOption{WithOSCALImports(imports)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.policy.ToOSCALProfile(tt.catalogs, tt.opts...)
+			assert.Error(t, err)
+		})
+	}
+}
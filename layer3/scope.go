@@ -0,0 +1,107 @@
+package layer3
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TargetInScope determines whether a target, described by its own boundaries, technologies and providers,
+// is in scope for the control reference with the provided ID. The policy-wide Scope and the reference's
+// InScope lists must each share at least one value with the target in every dimension they constrain, and the
+// target must not share any value with the reference's OutOfScope lists. Dimensions the target does not
+// describe are not constrained. If the target is out of scope, a human-readable reason is returned.
+func (p *PolicyDocument) TargetInScope(referenceId string, target Scope) (inScope bool, reason string) {
+	if reason := p.Scope.missing(target); reason != "" {
+		return false, fmt.Sprintf("%s is not in scope for policy %s", reason, p.Metadata.Id)
+	}
+	reference, ok := p.controlReference(referenceId)
+	if !ok {
+		return false, fmt.Sprintf("%s is not referenced by policy %s", referenceId, p.Metadata.Id)
+	}
+	if reason := reference.InScope.missing(target); reason != "" {
+		return false, fmt.Sprintf("%s is not in scope for %s in policy %s", reason, referenceId, p.Metadata.Id)
+	}
+	if reason := reference.OutOfScope.overlaps(target); reason != "" {
+		return false, fmt.Sprintf("%s is out of scope for %s in policy %s", reason, referenceId, p.Metadata.Id)
+	}
+	return true, ""
+}
+
+// Excluded determines whether the control or assessment requirement with the provided ID has been excluded
+// by a modification in the control reference with the provided ID. If so, the modification rationale is returned.
+func (p *PolicyDocument) Excluded(referenceId string, targetId string) (excluded bool, rationale string) {
+	reference, ok := p.controlReference(referenceId)
+	if !ok {
+		return false, ""
+	}
+	for _, mod := range reference.ControlModifications {
+		if mod.TargetId == targetId && mod.ModType == Exclude {
+			return true, mod.ModificationRationale
+		}
+	}
+	for _, mod := range reference.AssessmentRequirementModifications {
+		if mod.TargetId == targetId && mod.ModType == Exclude {
+			return true, mod.ModificationRationale
+		}
+	}
+	return false, ""
+}
+
+func (p *PolicyDocument) controlReference(referenceId string) (Mapping, bool) {
+	for _, reference := range p.ControlReferences {
+		if reference.ReferenceId == referenceId {
+			return reference, true
+		}
+	}
+	return Mapping{}, false
+}
+
+// missing returns a description of the first dimension in which the target has values but none are listed in
+// the scope, or an empty string if every constrained dimension is satisfied.
+func (s Scope) missing(target Scope) string {
+	for _, dimension := range s.compare(target) {
+		if len(dimension.scope) == 0 || len(dimension.target) == 0 {
+			continue
+		}
+		if len(intersect(dimension.scope, dimension.target)) == 0 {
+			return fmt.Sprintf("target %s '%s'", dimension.name, strings.Join(dimension.target, "', '"))
+		}
+	}
+	return ""
+}
+
+// overlaps returns a description of the first target value that is listed in the scope, or an empty string if there is none.
+func (s Scope) overlaps(target Scope) string {
+	for _, dimension := range s.compare(target) {
+		if shared := intersect(dimension.scope, dimension.target); len(shared) > 0 {
+			return fmt.Sprintf("target %s '%s'", dimension.name, shared[0])
+		}
+	}
+	return ""
+}
+
+type scopeDimension struct {
+	name   string
+	scope  []string
+	target []string
+}
+
+func (s Scope) compare(target Scope) []scopeDimension {
+	return []scopeDimension{
+		{name: "boundary", scope: s.Boundaries, target: target.Boundaries},
+		{name: "technology", scope: s.Technologies, target: target.Technologies},
+		{name: "provider", scope: s.Providers, target: target.Providers},
+	}
+}
+
+func intersect(a []string, b []string) (shared []string) {
+	for _, x := range b {
+		for _, y := range a {
+			if x == y {
+				shared = append(shared, x)
+				break
+			}
+		}
+	}
+	return shared
+}
@@ -0,0 +1,100 @@
+package layer3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTargetInScope(t *testing.T) {
+	policy := &PolicyDocument{}
+	require.NoError(t, policy.LoadFile("file://test-data/good-policy.yaml"))
+
+	tests := []struct {
+		name        string
+		referenceId string
+		target      Scope
+		inScope     bool
+	}{
+		{
+			name:        "Target matching policy and reference scope",
+			referenceId: "ISO-27001",
+			target: Scope{
+				Boundaries:   []string{"European Union"},
+				Technologies: []string{"Mobile Devices"},
+				Providers:    []string{"Google Cloud Platform"},
+			},
+			inScope: true,
+		},
+		{
+			name:        "Target without any descriptors",
+			referenceId: "ISO-27001",
+			target:      Scope{},
+			inScope:     true,
+		},
+		{
+			name:        "Target outside of policy scope",
+			referenceId: "ISO-27001",
+			target:      Scope{Boundaries: []string{"Australia"}},
+			inScope:     false,
+		},
+		{
+			name:        "Target in policy scope but not in reference scope",
+			referenceId: "ISO-27001",
+			target:      Scope{Technologies: []string{"Web Applications"}},
+			inScope:     false,
+		},
+		{
+			name:        "Target explicitly out of scope for reference",
+			referenceId: "ISO-27001",
+			target: Scope{
+				Technologies: []string{"Mobile Devices", "Legacy Systems"},
+			},
+			inScope: false,
+		},
+		{
+			name:        "Reference not in policy",
+			referenceId: "NIST-800-53",
+			target:      Scope{},
+			inScope:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inScope, reason := policy.TargetInScope(tt.referenceId, tt.target)
+			assert.Equal(t, tt.inScope, inScope)
+			if tt.inScope {
+				assert.Empty(t, reason)
+			} else {
+				assert.NotEmpty(t, reason)
+			}
+		})
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	policy := testPolicy(
+		[]ControlModifier{
+			{TargetId: "OSPS-AC-04", ModType: Exclude, ModificationRationale: "CI is managed centrally"},
+			{TargetId: "OSPS-AC-01", ModType: Clarify, ModificationRationale: "Align with corporate terminology"},
+		},
+		[]AssessmentRequirementModifier{
+			{TargetId: "OSPS-AC-03.02", ModType: Exclude, ModificationRationale: "Branch deletion is disabled"},
+		},
+	)
+
+	excluded, rationale := policy.Excluded("OSPS-B", "OSPS-AC-04")
+	assert.True(t, excluded)
+	assert.Equal(t, "CI is managed centrally", rationale)
+
+	excluded, rationale = policy.Excluded("OSPS-B", "OSPS-AC-03.02")
+	assert.True(t, excluded)
+	assert.Equal(t, "Branch deletion is disabled", rationale)
+
+	excluded, _ = policy.Excluded("OSPS-B", "OSPS-AC-01")
+	assert.False(t, excluded, "non-exclusion modifications should not exclude the target")
+
+	excluded, _ = policy.Excluded("OTHER", "OSPS-AC-04")
+	assert.False(t, excluded, "modifications for other references should not apply")
+}
@@ -0,0 +1,198 @@
+package layer3
+
+import (
+	"fmt"
+
+	"github.com/ossf/gemara/layer2"
+)
+
+// Supported values for ModType, as defined in the Layer 3 schema.
+const (
+	IncreaseStrictness ModType = "increase-strictness"
+	Clarify            ModType = "clarify"
+	ReduceStrictness   ModType = "reduce-strictness"
+	Exclude            ModType = "exclude"
+)
+
+// ModificationRecord describes a single modification that was applied while tailoring a referenced document.
+type ModificationRecord struct {
+	// ReferenceId is the identifier of the referenced document that was modified
+	ReferenceId string `json:"reference-id" yaml:"reference-id"`
+	// TargetId is the identifier of the control, requirement, guideline or part that was modified
+	TargetId string `json:"target-id" yaml:"target-id"`
+	// ModType is the type of modification that was applied
+	ModType ModType `json:"modification-type" yaml:"modification-type"`
+	// ModificationRationale is the reason given in the policy for the modification
+	ModificationRationale string `json:"modification-rationale" yaml:"modification-rationale"`
+	// ChangedFields lists the fields whose values were replaced by the modification
+	ChangedFields []string `json:"changed-fields,omitempty" yaml:"changed-fields,omitempty"`
+}
+
+func (m ModType) validate() error {
+	switch m {
+	case IncreaseStrictness, Clarify, ReduceStrictness, Exclude:
+		return nil
+	}
+	return fmt.Errorf("unsupported modification type: %q", m)
+}
+
+// EffectiveCatalog applies the control and assessment requirement modifications in the policy's
+// ControlReferences to the referenced Layer 2 Catalogs, producing a single tailored Catalog.
+// Each referenced catalog must be provided, identified by its Metadata.Id. Excluded controls and
+// requirements are removed, and all other modifications replace the values of the fields they set.
+// The returned records describe every modification that was applied, along with its rationale.
+func (p *PolicyDocument) EffectiveCatalog(catalogs []layer2.Catalog) (layer2.Catalog, []ModificationRecord, error) {
+	effective := layer2.Catalog{
+		Metadata: layer2.Metadata{
+			Id:           p.Metadata.Id,
+			Title:        p.Metadata.Title,
+			Description:  p.Metadata.Objective,
+			Version:      p.Metadata.Version,
+			LastModified: p.Metadata.LastModified,
+		},
+	}
+
+	catalogMap := make(map[string]layer2.Catalog)
+	for _, catalog := range catalogs {
+		catalogMap[catalog.Metadata.Id] = catalog
+	}
+
+	var records []ModificationRecord
+	for _, reference := range p.ControlReferences {
+		catalog, ok := catalogMap[reference.ReferenceId]
+		if !ok {
+			return layer2.Catalog{}, nil, fmt.Errorf("no catalog provided for control reference %s", reference.ReferenceId)
+		}
+		families, referenceRecords, err := tailorControlFamilies(catalog.ControlFamilies, reference)
+		if err != nil {
+			return layer2.Catalog{}, nil, fmt.Errorf("error applying modifications to %s: %w", reference.ReferenceId, err)
+		}
+		records = append(records, referenceRecords...)
+
+		effective.Metadata.ApplicabilityCategories = append(effective.Metadata.ApplicabilityCategories, catalog.Metadata.ApplicabilityCategories...)
+		effective.Metadata.MappingReferences = append(effective.Metadata.MappingReferences, layer2.MappingReference{
+			Id:          catalog.Metadata.Id,
+			Title:       catalog.Metadata.Title,
+			Version:     catalog.Metadata.Version,
+			Description: catalog.Metadata.Description,
+		})
+		effective.ControlFamilies = append(effective.ControlFamilies, families...)
+		effective.Threats = append(effective.Threats, catalog.Threats...)
+		effective.Capabilities = append(effective.Capabilities, catalog.Capabilities...)
+		effective.ImportedControls = append(effective.ImportedControls, catalog.ImportedControls...)
+		effective.ImportedThreats = append(effective.ImportedThreats, catalog.ImportedThreats...)
+		effective.ImportedCapabilities = append(effective.ImportedCapabilities, catalog.ImportedCapabilities...)
+	}
+	return effective, records, nil
+}
+
+// tailorControlFamilies returns a copy of the control families with the modifications from a single reference applied.
+func tailorControlFamilies(families []layer2.ControlFamily, reference Mapping) ([]layer2.ControlFamily, []ModificationRecord, error) {
+	controlMods := make(map[string]ControlModifier)
+	for _, mod := range reference.ControlModifications {
+		if err := mod.ModType.validate(); err != nil {
+			return nil, nil, fmt.Errorf("control modification %s: %w", mod.TargetId, err)
+		}
+		controlMods[mod.TargetId] = mod
+	}
+	requirementMods := make(map[string]AssessmentRequirementModifier)
+	for _, mod := range reference.AssessmentRequirementModifications {
+		if err := mod.ModType.validate(); err != nil {
+			return nil, nil, fmt.Errorf("assessment requirement modification %s: %w", mod.TargetId, err)
+		}
+		requirementMods[mod.TargetId] = mod
+	}
+
+	var records []ModificationRecord
+	applied := make(map[string]bool)
+	tailored := make([]layer2.ControlFamily, 0, len(families))
+	for _, family := range families {
+		controls := make([]layer2.Control, 0, len(family.Controls))
+		for _, control := range family.Controls {
+			if mod, ok := controlMods[control.Id]; ok {
+				applied[mod.TargetId] = true
+				record := newModificationRecord(reference.ReferenceId, mod.TargetId, mod.ModType, mod.ModificationRationale)
+				if mod.ModType == Exclude {
+					// Requirements of an excluded control need no further modification
+					for _, requirement := range control.AssessmentRequirements {
+						applied[requirement.Id] = true
+					}
+					records = append(records, record)
+					continue
+				}
+				record.ChangedFields = applyControlModifier(&control, mod)
+				records = append(records, record)
+			}
+
+			requirements := make([]layer2.AssessmentRequirement, 0, len(control.AssessmentRequirements))
+			for _, requirement := range control.AssessmentRequirements {
+				if mod, ok := requirementMods[requirement.Id]; ok {
+					applied[mod.TargetId] = true
+					record := newModificationRecord(reference.ReferenceId, mod.TargetId, mod.ModType, mod.ModificationRationale)
+					if mod.ModType == Exclude {
+						records = append(records, record)
+						continue
+					}
+					record.ChangedFields = applyAssessmentRequirementModifier(&requirement, mod)
+					records = append(records, record)
+				}
+				requirements = append(requirements, requirement)
+			}
+			control.AssessmentRequirements = requirements
+			controls = append(controls, control)
+		}
+		family.Controls = controls
+		tailored = append(tailored, family)
+	}
+
+	for _, mod := range reference.ControlModifications {
+		if !applied[mod.TargetId] {
+			return nil, nil, fmt.Errorf("control modification target %s not found", mod.TargetId)
+		}
+	}
+	for _, mod := range reference.AssessmentRequirementModifications {
+		if !applied[mod.TargetId] {
+			return nil, nil, fmt.Errorf("assessment requirement modification target %s not found", mod.TargetId)
+		}
+	}
+	return tailored, records, nil
+}
+
+// applyControlModifier replaces each control field that is set on the modifier, returning the names of the changed fields.
+func applyControlModifier(control *layer2.Control, mod ControlModifier) (changed []string) {
+	if mod.Title != "" {
+		control.Title = mod.Title
+		changed = append(changed, "title")
+	}
+	if mod.Objective != "" {
+		control.Objective = mod.Objective
+		changed = append(changed, "objective")
+	}
+	return changed
+}
+
+// applyAssessmentRequirementModifier replaces each requirement field that is set on the modifier, returning the names of the changed fields.
+func applyAssessmentRequirementModifier(requirement *layer2.AssessmentRequirement, mod AssessmentRequirementModifier) (changed []string) {
+	if mod.Text != "" {
+		requirement.Text = mod.Text
+		changed = append(changed, "text")
+	}
+	if len(mod.Applicability) > 0 {
+		requirement.Applicability = append([]string(nil), mod.Applicability...)
+		changed = append(changed, "applicability")
+	}
+	if mod.Recommendation != "" {
+		requirement.Recommendation = mod.Recommendation
+		changed = append(changed, "recommendation")
+	}
+	return changed
+}
+
+func newModificationRecord(referenceId, targetId string, modType ModType, rationale string) ModificationRecord {
+	return ModificationRecord{
+		ReferenceId:           referenceId,
+		TargetId:              targetId,
+		ModType:               modType,
+		ModificationRationale: rationale,
+	}
+}
@@ -0,0 +1,199 @@
+package layer3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ossf/gemara/layer2"
+)
+
+func testCatalog() layer2.Catalog {
+	return layer2.Catalog{
+		Metadata: layer2.Metadata{
+			Id:      "OSPS-B",
+			Title:   "Open Source Project Security Baseline",
+			Version: "2025.02.25",
+		},
+		ControlFamilies: []layer2.ControlFamily{
+			{
+				Id:    "AC",
+				Title: "Access Control",
+				Controls: []layer2.Control{
+					{
+						Id:        "OSPS-AC-01",
+						Title:     "Multi-factor authentication",
+						Objective: "Reduce the risk of account compromise",
+						AssessmentRequirements: []layer2.AssessmentRequirement{
+							{
+								Id:            "OSPS-AC-01.01",
+								Text:          "The system MUST require multi-factor authentication.",
+								Applicability: []string{"Maturity Level 1"},
+							},
+						},
+					},
+					{
+						Id:        "OSPS-AC-03",
+						Title:     "Branch protection",
+						Objective: "Prevent unreviewed changes to the primary branch",
+						AssessmentRequirements: []layer2.AssessmentRequirement{
+							{
+								Id:            "OSPS-AC-03.01",
+								Text:          "Direct commits to the primary branch MUST be prevented.",
+								Applicability: []string{"Maturity Level 1"},
+							},
+							{
+								Id:            "OSPS-AC-03.02",
+								Text:          "Deletion of the primary branch MUST require confirmation.",
+								Applicability: []string{"Maturity Level 1"},
+							},
+						},
+					},
+					{
+						Id:        "OSPS-AC-04",
+						Title:     "Least privilege CI",
+						Objective: "Limit the permissions of CI pipelines",
+						AssessmentRequirements: []layer2.AssessmentRequirement{
+							{
+								Id:            "OSPS-AC-04.01",
+								Text:          "CI pipelines MUST use the lowest available permissions.",
+								Applicability: []string{"Maturity Level 2"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func testPolicy(controlMods []ControlModifier, requirementMods []AssessmentRequirementModifier) PolicyDocument {
+	return PolicyDocument{
+		Metadata: Metadata{
+			Id:        "org-policy",
+			Title:     "Organization Policy",
+			Objective: "Tailor the baseline for the organization",
+			Version:   "1.0.0",
+		},
+		ControlReferences: []Mapping{
+			{
+				ReferenceId:                        "OSPS-B",
+				ControlModifications:               controlMods,
+				AssessmentRequirementModifications: requirementMods,
+			},
+		},
+	}
+}
+
+func TestEffectiveCatalog(t *testing.T) {
+	policy := testPolicy(
+		[]ControlModifier{
+			{
+				TargetId:              "OSPS-AC-04",
+				ModType:               Exclude,
+				ModificationRationale: "CI is managed centrally",
+			},
+			{
+				TargetId:              "OSPS-AC-01",
+				ModType:               Clarify,
+				ModificationRationale: "Align with corporate terminology",
+				Title:                 "Two-factor authentication",
+			},
+		},
+		[]AssessmentRequirementModifier{
+			{
+				TargetId:              "OSPS-AC-03.02",
+				ModType:               Exclude,
+				ModificationRationale: "Branch deletion is disabled organization-wide",
+			},
+			{
+				TargetId:              "OSPS-AC-03.01",
+				ModType:               IncreaseStrictness,
+				ModificationRationale: "Required for all maturity levels",
+				Text:                  "Direct commits to any protected branch MUST be prevented.",
+				Applicability:         []string{"Maturity Level 1", "Maturity Level 2", "Maturity Level 3"},
+				Recommendation:        "Use organization rulesets.",
+			},
+		},
+	)
+	source := testCatalog()
+
+	catalog, records, err := policy.EffectiveCatalog([]layer2.Catalog{source})
+	require.NoError(t, err)
+
+	assert.Equal(t, "org-policy", catalog.Metadata.Id)
+	require.Len(t, catalog.Metadata.MappingReferences, 1)
+	assert.Equal(t, "OSPS-B", catalog.Metadata.MappingReferences[0].Id)
+
+	require.Len(t, catalog.ControlFamilies, 1)
+	controls := catalog.ControlFamilies[0].Controls
+	require.Len(t, controls, 2, "excluded control should be removed")
+	assert.Equal(t, "Two-factor authentication", controls[0].Title)
+	assert.Equal(t, "Reduce the risk of account compromise", controls[0].Objective, "unset fields should not be modified")
+
+	require.Len(t, controls[1].AssessmentRequirements, 1, "excluded requirement should be removed")
+	requirement := controls[1].AssessmentRequirements[0]
+	assert.Equal(t, "Direct commits to any protected branch MUST be prevented.", requirement.Text)
+	assert.Len(t, requirement.Applicability, 3)
+	assert.Equal(t, "Use organization rulesets.", requirement.Recommendation)
+
+	assert.Equal(t, "Multi-factor authentication", source.ControlFamilies[0].Controls[0].Title, "source catalog should not be modified")
+	assert.Len(t, source.ControlFamilies[0].Controls[1].AssessmentRequirements, 2, "source catalog should not be modified")
+
+	require.Len(t, records, 4)
+	for _, record := range records {
+		assert.Equal(t, "OSPS-B", record.ReferenceId)
+		assert.NotEmpty(t, record.ModificationRationale)
+		switch record.TargetId {
+		case "OSPS-AC-01":
+			assert.Equal(t, []string{"title"}, record.ChangedFields)
+		case "OSPS-AC-03.01":
+			assert.Equal(t, []string{"text", "applicability", "recommendation"}, record.ChangedFields)
+		default:
+			assert.Equal(t, Exclude, record.ModType)
+			assert.Empty(t, record.ChangedFields)
+		}
+	}
+}
+
+func TestEffectiveCatalog_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   PolicyDocument
+		catalogs []layer2.Catalog
+	}{
+		{
+			name:     "Missing referenced catalog",
+			policy:   testPolicy(nil, nil),
+			catalogs: nil,
+		},
+		{
+			name: "Unsupported modification type",
+			policy: testPolicy([]ControlModifier{
+				{TargetId: "OSPS-AC-01", ModType: "enhancement"},
+			}, nil),
+			catalogs: []layer2.Catalog{testCatalog()},
+		},
+		{
+			name: "Control modification target not found",
+			policy: testPolicy([]ControlModifier{
+				{TargetId: "OSPS-AC-99", ModType: Clarify},
+			}, nil),
+			catalogs: []layer2.Catalog{testCatalog()},
+		},
+		{
+			name: "Assessment requirement modification target not found",
+			policy: testPolicy(nil, []AssessmentRequirementModifier{
+				{TargetId: "OSPS-AC-99.01", ModType: Clarify},
+			}),
+			catalogs: []layer2.Catalog{testCatalog()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := tt.policy.EffectiveCatalog(tt.catalogs)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -1,50 +1,151 @@
 package layer4
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
 	"runtime"
+	"runtime/debug"
+	"sort"
 	"time"
 )
 
 // Assessment is a struct that contains the results of a single step within a ControlEvaluation.
 type Assessment struct {
 	// RequirementID is the unique identifier for the requirement being tested
-	RequirementId string `yaml:"requirement-id"`
+	RequirementId string `json:"requirement-id" yaml:"requirement-id"`
 	// Applicability is a slice of identifier strings to determine when this test is applicable
-	Applicability []string `yaml:"applicability"`
+	Applicability []string `json:"applicability" yaml:"applicability"`
 	// Description is a human-readable description of the test
-	Description string `yaml:"description"`
+	Description string `json:"description" yaml:"description"`
 	// Result is true if the test passed
-	Result Result `yaml:"result"`
+	Result Result `json:"result" yaml:"result"`
 	// Message is the human-readable result of the test
-	Message string `yaml:"message"`
+	Message string `json:"message" yaml:"message"`
 	// Steps is a slice of steps that were executed during the test
-	Steps []AssessmentStep `yaml:"steps"`
+	Steps []AssessmentStep `json:"steps" yaml:"steps"`
 	// StepsExecuted is the number of steps that were executed during the test
-	StepsExecuted int `yaml:"steps-executed,omitempty"`
+	StepsExecuted int `json:"steps-executed,omitempty" yaml:"steps-executed,omitempty"`
+	// StepResults records the outcome of each step in the order that the steps were executed
+	StepResults []StepResult `json:"step-results,omitempty" yaml:"step-results,omitempty"`
 	// Start is the time the assessment run began.
-	Start string `yaml:"start"`
+	Start string `json:"start" yaml:"start"`
 	// End is the time the assessment run finished.
 	// This is omitted if the assessment was interrupted or did not complete.
-	End string `yaml:"end,omitempty"`
+	End string `json:"end,omitempty" yaml:"end,omitempty"`
 	// Value is the object that was returned during the test
-	Value interface{} `yaml:"value,omitempty"`
+	Value interface{} `json:"value,omitempty" yaml:"value,omitempty"`
+	// Evidence is a slice of evidence collected to support the result
+	Evidence []Evidence `json:"evidence,omitempty" yaml:"evidence,omitempty"`
 	// Changes is a slice of changes that were made during the test
-	Changes map[string]*Change `yaml:"changes,omitempty"`
+	Changes map[string]*Change `json:"changes,omitempty" yaml:"changes,omitempty"`
 	// Recommendation is a string to aid users in remediation, such as the text from a layer 2 assessment requirement
-	Recommendation string `yaml:"recommendation,omitempty"`
+	Recommendation string `json:"recommendation,omitempty" yaml:"recommendation,omitempty"`
+	// stepNames holds the serialized names of steps that were loaded from previous results
+	stepNames []string
+	// guard serializes the application and reversion of Changes while the assessment is run
+	guard *changeGuard
 }
 
 // AssessmentStep is a function type that inspects the provided targetData and returns a Result with a message.
 // The message may be an error string or other descriptive text.
 type AssessmentStep func(payload interface{}, c map[string]*Change) (Result, string)
 
+// ContextAssessmentStep is an AssessmentStep that also receives the context of the running assessment,
+// so that it can honor cancellation and deadlines.
+type ContextAssessmentStep func(ctx context.Context, payload interface{}, c map[string]*Change) (Result, string)
+
+// stepCall is passed as the payload to steps created by wrapStep, carrying the context and the target data.
+// If name is set, the step reports its declared name and description instead of running.
+type stepCall struct {
+	ctx         context.Context
+	payload     interface{}
+	name        *string
+	description *string
+}
+
+// ContextStep adapts a ContextAssessmentStep so that it can be used anywhere an AssessmentStep is accepted.
+// When the step is run by Run or Evaluate rather than their context-aware variants, it receives context.Background().
+func ContextStep(step ContextAssessmentStep) AssessmentStep {
+	return wrapStep(functionName(step), "", step)
+}
+
+// wrapStep creates an AssessmentStep that receives the context from callStep and reports the provided name
+// and description.
+//
+//go:noinline
+func wrapStep(name, description string, step ContextAssessmentStep) AssessmentStep {
+	return func(payload interface{}, c map[string]*Change) (Result, string) {
+		call, ok := payload.(*stepCall)
+		if !ok {
+			return step(context.Background(), payload, c)
+		}
+		if call.name != nil {
+			*call.name = name
+			if call.description != nil {
+				*call.description = description
+			}
+			return NotRun, ""
+		}
+		return step(call.ctx, call.payload, c)
+	}
+}
+
+// wrappedStepPointer identifies steps created by wrapStep. wrapStep is never inlined, so every step it
+// creates shares the same underlying function. This constraint is described in the package documentation and
+// tested with inlining disabled.
+var wrappedStepPointer = reflect.ValueOf(wrapStep("", "", nil)).Pointer()
+
+func isWrappedStep(step AssessmentStep) bool {
+	return reflect.ValueOf(step).Pointer() == wrappedStepPointer
+}
+
+// callStep runs the step, passing the context along with the target data to steps created by wrapStep.
+// A panic in the step is recovered and returned as an Unknown result with the stack trace in the message.
+func callStep(ctx context.Context, step AssessmentStep, targetData interface{}, changes map[string]*Change) (result Result, message string) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result, message = Unknown, fmt.Sprintf("step %s failed: %s", step, panicError(recovered))
+		}
+	}()
+	if isWrappedStep(step) {
+		return step(&stepCall{ctx: ctx, payload: targetData}, changes)
+	}
+	return step(targetData, changes)
+}
+
+// String returns the declared name of a step created by NamedStep, ContextStep or Step, falling back to the
+// fully qualified name of the function for other steps.
 func (as AssessmentStep) String() string {
+	if isWrappedStep(as) {
+		var name string
+		as(&stepCall{name: &name}, nil)
+		return name
+	}
+	return functionName(as)
+}
+
+// Description returns the declared description of a step created by NamedStep, or an empty string.
+func (as AssessmentStep) Description() string {
+	if isWrappedStep(as) {
+		var name, description string
+		as(&stepCall{name: &name, description: &description}, nil)
+		return description
+	}
+	return ""
+}
+
+// panicError converts a recovered panic into an error that includes the stack trace of the panicking goroutine.
+func panicError(recovered interface{}) error {
+	return fmt.Errorf("panic: %v\n%s", recovered, debug.Stack())
+}
+
+// functionName returns the fully qualified name of the provided function.
+func functionName(function interface{}) string {
 	// Get the function pointer correctly
-	fn := runtime.FuncForPC(reflect.ValueOf(as).Pointer())
+	fn := runtime.FuncForPC(reflect.ValueOf(function).Pointer())
 	if fn == nil {
 		return "<unknown function>"
 	}
@@ -59,6 +160,122 @@ func (as AssessmentStep) MarshalYAML() (interface{}, error) {
 	return as.String(), nil
 }
 
+// UnmarshalJSON replaces the serialized step with a placeholder, because functions cannot be loaded from JSON.
+func (as *AssessmentStep) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	*as = loadedStep
+	return nil
+}
+
+// UnmarshalYAML replaces the serialized step with a placeholder, because functions cannot be loaded from YAML.
+func (as *AssessmentStep) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err != nil {
+		return err
+	}
+	*as = loadedStep
+	return nil
+}
+
+// loadedStep is the placeholder for an AssessmentStep that was loaded from previous results.
+// The original function is not available, so it can only report that the step needs review.
+func loadedStep(interface{}, map[string]*Change) (Result, string) {
+	return NeedsReview, "step was loaded from previous results and cannot be executed"
+}
+
+// isLoadedStep returns true if the step is a placeholder created while loading previous results.
+func isLoadedStep(step AssessmentStep) bool {
+	return reflect.ValueOf(step).Pointer() == reflect.ValueOf(loadedStep).Pointer()
+}
+
+// StepResult is the outcome of a single step executed during an assessment.
+type StepResult struct {
+	// Name is the name of the step, as returned by AssessmentStep.String
+	Name string `json:"name" yaml:"name"`
+	// Result is the result returned by the step
+	Result Result `json:"result" yaml:"result"`
+	// Message is the human-readable message returned by the step
+	Message string `json:"message" yaml:"message"`
+	// Start is the time the step began.
+	Start string `json:"start" yaml:"start"`
+	// End is the time the step returned.
+	// This is omitted if the step was interrupted.
+	End string `json:"end,omitempty" yaml:"end,omitempty"`
+	// Attempt is the number of this attempt, if the step was run with a RetryPolicy
+	Attempt int `json:"attempt,omitempty" yaml:"attempt,omitempty"`
+}
+
+// serializedAssessment is used to swap the Steps of an Assessment for their names during serialization.
+type serializedAssessment struct {
+	*plainAssessment `yaml:",inline"`
+	Steps            []string `json:"steps" yaml:"steps"`
+}
+
+// plainAssessment has the fields of Assessment without its custom serialization methods.
+type plainAssessment Assessment
+
+// stepNameList returns the name of each step in the Assessment, preferring the original names of loaded steps.
+func (a *Assessment) stepNameList() []string {
+	names := make([]string, len(a.Steps))
+	for i, step := range a.Steps {
+		if isLoadedStep(step) && i < len(a.stepNames) {
+			names[i] = a.stepNames[i]
+			continue
+		}
+		names[i] = step.String()
+	}
+	return names
+}
+
+// MarshalJSON serializes the Assessment, preserving the original names of any loaded steps.
+func (a Assessment) MarshalJSON() ([]byte, error) {
+	return json.Marshal(serializedAssessment{
+		plainAssessment: (*plainAssessment)(&a),
+		Steps:           a.stepNameList(),
+	})
+}
+
+// MarshalYAML serializes the Assessment, preserving the original names of any loaded steps.
+func (a Assessment) MarshalYAML() (interface{}, error) {
+	return serializedAssessment{
+		plainAssessment: (*plainAssessment)(&a),
+		Steps:           a.stepNameList(),
+	}, nil
+}
+
+// UnmarshalJSON loads an Assessment from previous results. Steps are replaced with placeholders.
+func (a *Assessment) UnmarshalJSON(data []byte) error {
+	var names struct {
+		Steps []string `json:"steps"`
+	}
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, (*plainAssessment)(a)); err != nil {
+		return err
+	}
+	a.stepNames = names.Steps
+	return nil
+}
+
+// UnmarshalYAML loads an Assessment from previous results. Steps are replaced with placeholders.
+func (a *Assessment) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var names struct {
+		Steps []string `yaml:"steps"`
+	}
+	if err := unmarshal(&names); err != nil {
+		return err
+	}
+	if err := unmarshal((*plainAssessment)(a)); err != nil {
+		return err
+	}
+	a.stepNames = names.Steps
+	return nil
+}
+
 // NewAssessment creates a new Assessment object and returns a pointer to it.
 func NewAssessment(requirementId string, description string, applicability []string, steps []AssessmentStep) (*Assessment, error) {
 	a := &Assessment{
@@ -77,19 +294,114 @@ func (a *Assessment) AddStep(step AssessmentStep) {
 	a.Steps = append(a.Steps, step)
 }
 
-func (a *Assessment) runStep(targetData interface{}, step AssessmentStep) Result {
+// runStep executes a single step, recording its result and message on the Assessment. If the context is done
+// before the step returns, the step is recorded as Unknown and the context error is returned. The step is left
+// running in the background, because it cannot be stopped unless it is a ContextAssessmentStep.
+func (a *Assessment) runStep(ctx context.Context, targetData interface{}, step AssessmentStep, timeout time.Duration) (Result, error) {
+	record, err := a.retryStep(ctx, targetData, step, timeout, RetryPolicy{})
+	return record.Result, err
+}
+
+// retryStep executes a single step in the same way as runStep, running it again while the policy allows.
+// Every attempt is recorded in StepResults, but only the final attempt contributes to the Assessment result.
+// The outcome of the final attempt is returned, with its own message even if the Assessment keeps an earlier one.
+func (a *Assessment) retryStep(ctx context.Context, targetData interface{}, step AssessmentStep, timeout time.Duration, policy RetryPolicy) (StepResult, error) {
 	a.StepsExecuted++
-	result, message := step(targetData, a.Changes)
-	a.Result = UpdateAggregateResult(a.Result, result)
-	a.Message = message
-	return result
+	for attempt := 1; ; attempt++ {
+		record, err := a.attemptStep(ctx, targetData, step, timeout)
+		if policy.Attempts > 1 {
+			record.Attempt = attempt
+		}
+		a.StepResults = append(a.StepResults, record)
+		// An attempt that exceeded the step timeout is retried like any other Unknown result,
+		// unless the context of the assessment is also done
+		if (err == nil || ctx.Err() == nil) && policy.retry(attempt, record.Result) {
+			waitErr := policy.wait(ctx, attempt)
+			switch {
+			case waitErr != nil:
+				err = waitErr
+				record.Result = Unknown
+				record.Message = fmt.Sprintf("step %s did not complete: %s", step, err)
+			case a.guardChanges().stepsRunning():
+				// Another attempt could apply changes while the attempt that timed out is still applying them
+				record.Message = fmt.Sprintf("%s; step %s was not retried because a previous attempt is still running", record.Message, step)
+			default:
+				continue
+			}
+		}
+		if err != nil {
+			a.Result = UpdateAggregateResult(a.Result, Unknown)
+			a.Message = record.Message
+			return record, err
+		}
+		// Keep the message of a failed step when later steps are run after the failure
+		if a.Result != Failed || record.Result == Failed {
+			a.Message = record.Message
+		}
+		a.Result = UpdateAggregateResult(a.Result, record.Result)
+		return record, nil
+	}
+}
+
+// attemptStep executes a step once, collecting any evidence it attaches. If the context is done before the step
+// returns, the attempt is recorded as Unknown and the context error is returned.
+func (a *Assessment) attemptStep(ctx context.Context, targetData interface{}, step AssessmentStep, timeout time.Duration) (StepResult, error) {
+	record := StepResult{
+		Name:  step.String(),
+		Start: time.Now().Format(time.RFC3339),
+	}
+	collector := &evidenceCollector{step: record.Name}
+	ctx = context.WithValue(ctx, evidenceCollectorKey{}, collector)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if ctx.Done() == nil {
+		record.Result, record.Message = callStep(ctx, step, targetData, a.Changes)
+	} else {
+		type stepOutput struct {
+			result  Result
+			message string
+		}
+		output := make(chan stepOutput, 1)
+		guard := a.guardChanges()
+		guard.stepStarted()
+		go func() {
+			defer guard.stepReturned()
+			result, message := callStep(ctx, step, targetData, a.Changes)
+			output <- stepOutput{result: result, message: message}
+		}()
+		select {
+		case out := <-output:
+			record.Result, record.Message = out.result, out.message
+		case <-ctx.Done():
+			record.Result = Unknown
+			record.Message = fmt.Sprintf("step %s did not complete: %s", step, ctx.Err())
+			a.Evidence = append(a.Evidence, collector.close()...)
+			return record, ctx.Err()
+		}
+	}
+	record.End = time.Now().Format(time.RFC3339)
+	a.Evidence = append(a.Evidence, collector.close()...)
+	return record, nil
 }
 
 // Run will execute all steps, halting if any step does not return layer4.Passed.
 func (a *Assessment) Run(targetData interface{}, changesAllowed bool) Result {
+	return a.RunContext(context.Background(), targetData, changesAllowed)
+}
+
+// RunContext will execute all steps in the same way as Run, halting if the context is done or a timeout from the
+// provided options is reached. A step that is interrupted is recorded as Unknown with a message describing the
+// interruption, and any changes made by the assessment are reverted. With WithContinueOnFailure, the remaining
+// steps are run after a Failed step.
+func (a *Assessment) RunContext(ctx context.Context, targetData interface{}, changesAllowed bool, opts ...RunOption) Result {
 	if a.Result != NotRun {
 		return a.Result
 	}
+	options := newRunOpts(opts)
 
 	a.Start = time.Now().Format(time.RFC3339)
 	err := a.precheck()
@@ -97,13 +409,21 @@ func (a *Assessment) Run(targetData interface{}, changesAllowed bool) Result {
 		a.Result = Unknown
 		return a.Result
 	}
-	for _, change := range a.Changes {
-		if changesAllowed {
-			change.Allow()
-		}
+	options.observer.OnAssessmentStart(a)
+	a.prepareChanges(options, changesAllowed)
+	if options.assessmentTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.assessmentTimeout)
+		defer cancel()
 	}
 	for _, step := range a.Steps {
-		if a.runStep(targetData, step) == Failed {
+		record, err := a.retryStep(ctx, targetData, step, options.stepTimeout, options.retry)
+		options.observer.OnStepResult(a, step, record.Result, record.Message)
+		if err != nil {
+			a.RevertChanges()
+			return a.Result
+		}
+		if record.Result == Failed && !options.continueOnFailure {
 			return Failed
 		}
 	}
@@ -111,6 +431,55 @@ func (a *Assessment) Run(targetData interface{}, changesAllowed bool) Result {
 	return a.Result
 }
 
+// prepareChanges configures every Change with the options of the run, while holding the guard so that the
+// changes are not reverted at the same time.
+func (a *Assessment) prepareChanges(options runOpts, changesAllowed bool) {
+	guard := a.guardChanges()
+	guard.mutex.Lock()
+	defer guard.mutex.Unlock()
+	for name, change := range a.Changes {
+		change.guard = guard
+		change.observer = options.observer
+		change.dryRun = options.dryRun
+		change.journal = options.journal
+		change.name = name
+		change.requirementId = a.RequirementId
+		if changesAllowed {
+			change.Allow()
+		}
+	}
+}
+
+// guardChanges returns the changeGuard of the Assessment, creating it if required. It is called before the
+// assessment is shared with other goroutines, such as the CleanupCoordinator, so that only one guard is created.
+func (a *Assessment) guardChanges() *changeGuard {
+	if a.guard == nil {
+		a.guard = &changeGuard{}
+	}
+	return a.guard
+}
+
+// isApplicable returns true if any of the assessment's applicability values are in the userApplicability.
+func (a *Assessment) isApplicable(userApplicability []string) bool {
+	for _, aa := range a.Applicability {
+		for _, ua := range userApplicability {
+			if aa == ua {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// markNotApplicable records that the assessment was not run because it does not apply to the target.
+func (a *Assessment) markNotApplicable(message string) {
+	now := time.Now().Format(time.RFC3339)
+	a.Result = NotApplicable
+	a.Message = message
+	a.Start = now
+	a.End = now
+}
+
 // NewChange creates a new Change object and adds it to the Assessment.
 func (a *Assessment) NewChange(
 	changeName,
@@ -121,6 +490,7 @@ func (a *Assessment) NewChange(
 	revertFunc RevertFunc,
 ) *Change {
 	change := NewChange(targetName, description, targetObject, applyFunc, revertFunc)
+	change.guard = a.guard
 	if a.Changes == nil {
 		a.Changes = make(map[string]*Change)
 	}
@@ -128,20 +498,93 @@ func (a *Assessment) NewChange(
 	return &change
 }
 
-// RevertChanges reverts all changes made by the assessment.
-// It will not revert changes that have not been applied.
+// RevertChanges reverts all changes made by the assessment, in the reverse of the order they were applied.
+// It will not revert changes that have not been applied. Every change is attempted, even after a failure.
+// Changes can no longer be applied afterward. The state is reported as corrupted if a step that was interrupted by
+// a timeout is still running, because it may continue to modify the target.
 func (a *Assessment) RevertChanges() (corrupted bool) {
-	for _, change := range a.Changes {
-		if !corrupted && (change.Applied || change.Error != nil) {
-			if !change.Reverted {
-				change.Revert(nil)
-			}
-			if change.Error != nil || !change.Reverted {
-				corrupted = true // do not break loop here; continue attempting to revert all changes
-			}
+	return len(a.revertChanges()) > 0
+}
+
+// revertChanges reverts all changes made by the assessment in the reverse of the order they were applied,
+// returning an error for each change that could not be reverted. No change may be applied afterward. If a step
+// is still running in the background, an error is returned because the target may still be modified by the step.
+// A change whose apply function has not returned is not waited for; it is reported as an error, and is reverted
+// by Change.Apply once the apply function returns.
+func (a *Assessment) revertChanges() (errs []error) {
+	guard := a.guardChanges()
+	guard.mutex.Lock()
+	defer guard.mutex.Unlock()
+	guard.reverting = true
+	if guard.running > 0 && len(a.Changes) > 0 {
+		guard.abandoned = true
+	}
+	if guard.abandoned {
+		errs = append(errs, fmt.Errorf("a step of assessment %s was still running when its changes were reverted", a.RequirementId))
+	}
+	for _, name := range a.applyingChangeNames() {
+		errs = append(errs, fmt.Errorf("change %s was still being applied when the changes were reverted", name))
+	}
+	for _, name := range a.appliedChangeNames() {
+		change := a.Changes[name]
+		if !change.Reverted {
+			change.revert(nil)
 		}
+		if change.Error != nil {
+			errs = append(errs, fmt.Errorf("error reverting change %s: %w", name, change.Error))
+		} else if !change.Reverted {
+			errs = append(errs, fmt.Errorf("change %s was not reverted", name))
+		}
+	}
+	return errs
+}
+
+// appliedChangeNames returns the names of changes that were applied or have an error, most recently applied first.
+// Changes without a recorded application order, such as those loaded from previous results, are last, ordered by name.
+// Changes that are still being applied are left out.
+func (a *Assessment) appliedChangeNames() []string {
+	var names []string
+	for name, change := range a.Changes {
+		if !change.applying && (change.Applied || change.Error != nil) {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		first, second := a.Changes[names[i]].sequence, a.Changes[names[j]].sequence
+		if first != second {
+			return first > second
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// applyingChangeNames returns the names of changes whose apply function is running, ordered by name.
+func (a *Assessment) applyingChangeNames() []string {
+	var names []string
+	for name, change := range a.Changes {
+		if change.applying {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PlannedChanges returns the changes that were recorded during a dry run, ordered by name.
+func (a *Assessment) PlannedChanges() []*Change {
+	names := make([]string, 0, len(a.Changes))
+	for name, change := range a.Changes {
+		if change.Planned {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	planned := make([]*Change, len(names))
+	for i, name := range names {
+		planned[i] = a.Changes[name]
 	}
-	return
+	return planned
 }
 
 // precheck verifies that the assessment has all the required fields.
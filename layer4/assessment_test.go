@@ -1,7 +1,15 @@
 package layer4
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func getAssessmentsTestData() []struct {
@@ -99,7 +107,7 @@ func TestRunStep(t *testing.T) {
 	for _, test := range stepsTestData {
 		t.Run(test.testName, func(t *testing.T) {
 			anyOldAssessment := Assessment{}
-			result := anyOldAssessment.runStep(nil, test.step)
+			result, _ := anyOldAssessment.runStep(context.Background(), nil, test.step, 0)
 			if result != test.result {
 				t.Errorf("expected %s, got %s", test.result, result)
 			}
@@ -293,3 +301,235 @@ func TestNewAssessment(t *testing.T) {
 		})
 	}
 }
+
+func TestRunContext(t *testing.T) {
+	hangingStep := func(interface{}, map[string]*Change) (Result, string) {
+		time.Sleep(time.Second)
+		return Passed, ""
+	}
+	var receivedDeadline bool
+	contextStep := ContextStep(func(ctx context.Context, payload interface{}, c map[string]*Change) (Result, string) {
+		_, receivedDeadline = ctx.Deadline()
+		return Passed, ""
+	})
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		testName       string
+		ctx            context.Context
+		steps          []AssessmentStep
+		opts           []RunOption
+		expectedResult Result
+		expectedSteps  int
+	}{
+		{
+			testName:       "Step timeout",
+			ctx:            context.Background(),
+			steps:          []AssessmentStep{passingAssessmentStep, hangingStep, passingAssessmentStep},
+			opts:           []RunOption{WithStepTimeout(10 * time.Millisecond)},
+			expectedResult: Unknown,
+			expectedSteps:  2,
+		},
+		{
+			testName:       "Assessment timeout",
+			ctx:            context.Background(),
+			steps:          []AssessmentStep{hangingStep, passingAssessmentStep},
+			opts:           []RunOption{WithAssessmentTimeout(10 * time.Millisecond)},
+			expectedResult: Unknown,
+			expectedSteps:  1,
+		},
+		{
+			testName:       "Cancelled context",
+			ctx:            cancelledCtx,
+			steps:          []AssessmentStep{passingAssessmentStep},
+			expectedResult: Unknown,
+			expectedSteps:  1,
+		},
+		{
+			testName:       "Context step within timeout",
+			ctx:            context.Background(),
+			steps:          []AssessmentStep{contextStep, passingAssessmentStep},
+			opts:           []RunOption{WithStepTimeout(time.Second)},
+			expectedResult: Passed,
+			expectedSteps:  2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			change := pendingChangePtr()
+			a := &Assessment{
+				RequirementId: "test",
+				Description:   "test",
+				Applicability: testingApplicability,
+				Steps:         test.steps,
+				Changes:       map[string]*Change{"pendingChange": change},
+			}
+			change.Allow()
+			change.Apply("target_name", "target_object", "change_input")
+
+			result := a.RunContext(test.ctx, nil, true, test.opts...)
+			if result != test.expectedResult {
+				t.Errorf("Expected %s, got %s", test.expectedResult, result)
+			}
+			if a.StepsExecuted != test.expectedSteps {
+				t.Errorf("Expected to run %d steps, got %d", test.expectedSteps, a.StepsExecuted)
+			}
+			if test.expectedResult == Unknown {
+				if a.Message == "" || a.End != "" {
+					t.Errorf("Expected an interruption message and no end time, got message=%q, end=%q", a.Message, a.End)
+				}
+				if !change.Reverted {
+					t.Errorf("Expected changes to be reverted after the interruption")
+				}
+			}
+		})
+	}
+	if !receivedDeadline {
+		t.Errorf("Expected the context step to receive the step deadline")
+	}
+}
+
+func TestContextStepString(t *testing.T) {
+	step := ContextStep(func(ctx context.Context, payload interface{}, c map[string]*Change) (Result, string) {
+		return Passed, ""
+	})
+	if step.String() != "github.com/ossf/gemara/layer4.TestContextStepString.func1" {
+		t.Errorf("Expected the name of the wrapped function, got %s", step.String())
+	}
+	if result, _ := step(nil, nil); result != Passed {
+		t.Errorf("Expected a context step to run without a context, got %s", result)
+	}
+}
+
+// TestWrappedSteps ensures that the steps created by each adapter are recognized as wrapped steps and receive
+// their target data, and that other steps are not recognized as wrapped steps
+func TestWrappedSteps(t *testing.T) {
+	wrapped := map[string]AssessmentStep{
+		"ContextStep": ContextStep(func(_ context.Context, payload interface{}, c map[string]*Change) (Result, string) {
+			return typedTargetStep(payload.(*typedTarget), c)
+		}),
+		"Step":      Step(typedTargetStep),
+		"NamedStep": NamedStep{Name: "named", Func: Step(typedTargetStep)}.Step(),
+	}
+	for name, step := range wrapped {
+		if !isWrappedStep(step) {
+			t.Errorf("Expected the step created by %s to be recognized as a wrapped step", name)
+		}
+		if result, message := callStep(context.Background(), step, &typedTarget{Enabled: true}, nil); result != Passed {
+			t.Errorf("Expected the step created by %s to receive the target data, got %s: %s", name, result, message)
+		}
+	}
+	closure := func(interface{}, map[string]*Change) (Result, string) {
+		return Passed, ""
+	}
+	for _, step := range []AssessmentStep{passingAssessmentStep, closure, loadedStep} {
+		if isWrappedStep(step) {
+			t.Errorf("Expected %s not to be recognized as a wrapped step", step)
+		}
+	}
+}
+
+// TestWrappedStepsWithoutInlining runs the tests of wrapped steps again with inlining disabled, because wrapped
+// steps are recognized by the code pointer of the closure created by wrapStep
+func TestWrappedStepsWithoutInlining(t *testing.T) {
+	if testing.Short() || os.Getenv("GEMARA_NOINLINE_TEST") != "" {
+		t.Skip("the tests of wrapped steps are already running")
+	}
+	goCommand, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the go command is not available")
+	}
+	cmd := exec.Command(goCommand, "test", "-count=1", "-gcflags=all=-l",
+		"-run", "^(TestWrappedSteps|TestContextStepString|TestNamedStep|TestStep)$", ".")
+	cmd.Env = append(os.Environ(), "GEMARA_NOINLINE_TEST=1")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Expected the tests of wrapped steps to pass without inlining: %v\n%s", err, output)
+	}
+}
+
+// TestStepResults ensures that the outcome of each executed step is recorded and serialized
+func TestStepResults(t *testing.T) {
+	messageStep := func(interface{}, map[string]*Change) (Result, string) {
+		return NeedsReview, "needs a human"
+	}
+	assessment := Assessment{
+		RequirementId: "step-results",
+		Description:   "step results",
+		Applicability: testingApplicability,
+		Steps:         []AssessmentStep{passingAssessmentStep, messageStep, failingAssessmentStep, passingAssessmentStep},
+	}
+	assessment.Run(nil, false)
+
+	expected := []struct {
+		result  Result
+		message string
+	}{
+		{Passed, ""},
+		{NeedsReview, "needs a human"},
+		{Failed, ""},
+	}
+	if len(assessment.StepResults) != len(expected) {
+		t.Fatalf("Expected %d step results, got %d", len(expected), len(assessment.StepResults))
+	}
+	for i, want := range expected {
+		got := assessment.StepResults[i]
+		if got.Result != want.result || got.Message != want.message {
+			t.Errorf("step %d: expected %s %q, got %s %q", i, want.result, want.message, got.Result, got.Message)
+		}
+		if got.Name != assessment.Steps[i].String() {
+			t.Errorf("step %d: expected name %s, got %s", i, assessment.Steps[i].String(), got.Name)
+		}
+		if got.Start == "" || got.End == "" {
+			t.Errorf("step %d: expected start and end times to be recorded", i)
+		}
+	}
+
+	data, err := json.Marshal(assessment)
+	if err != nil {
+		t.Fatalf("Unexpected error marshalling assessment: %v", err)
+	}
+	var loaded Assessment
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("Unexpected error unmarshalling assessment: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.StepResults, assessment.StepResults) {
+		t.Errorf("Expected step results to round-trip, got %+v", loaded.StepResults)
+	}
+}
+
+// TestRevertChangesOrder ensures that changes are reverted in the reverse of the order they were applied,
+// and that every change is attempted after a failure
+func TestRevertChangesOrder(t *testing.T) {
+	var reverted []string
+	newChange := func(name string, revertErr error) *Change {
+		change := pendingChangePtr()
+		change.revertFunc = func(interface{}) error {
+			reverted = append(reverted, name)
+			return revertErr
+		}
+		change.Allow()
+		return change
+	}
+	assessment := Assessment{Changes: map[string]*Change{
+		"a-protect-branch": newChange("a-protect-branch", nil),
+		"b-broken":         newChange("b-broken", errors.New("revert failed")),
+		"c-create-branch":  newChange("c-create-branch", nil),
+	}}
+	for _, name := range []string{"c-create-branch", "b-broken", "a-protect-branch"} {
+		assessment.Changes[name].Apply("target_name", "target_object", "change_input")
+	}
+
+	errs := assessment.revertChanges()
+
+	expected := []string{"a-protect-branch", "b-broken", "c-create-branch"}
+	if !reflect.DeepEqual(reverted, expected) {
+		t.Errorf("Expected changes to be reverted in order %v, got %v", expected, reverted)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "b-broken") {
+		t.Errorf("Expected one error for b-broken, got %v", errs)
+	}
+	if !assessment.Changes["c-create-branch"].Reverted {
+		t.Errorf("Expected changes after a failure to be reverted")
+	}
+}
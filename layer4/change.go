@@ -1,35 +1,177 @@
 package layer4
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
 )
 
 // Prepared function to apply the change
 type ApplyFunc func(interface{}) (interface{}, error)
 
-// Prepared function to revert the change after it has been applied
+// Prepared function to revert the change after it has been applied. It receives the data passed to Change.Revert,
+// which is nil when changes are reverted at the end of an evaluation, or the recorded TargetObject when it is
+// called by Journal.Recover.
 type RevertFunc func(interface{}) error
 
+// changeSequence is incremented each time a change is applied
+var changeSequence atomic.Uint64
+
+// changeGuard serializes access to the Changes of an Assessment, so that a step which is still running after
+// a timeout cannot apply a change while or after the changes of the assessment are reverted.
+type changeGuard struct {
+	mutex sync.Mutex
+	// reverting is set when the changes are reverted, after which no change may be applied
+	reverting bool
+	// running is the number of steps that were started in the background and have not returned
+	running int
+	// abandoned is set if the changes were reverted while a step was still running
+	abandoned bool
+}
+
+// stepStarted records that a step is running in the background.
+func (g *changeGuard) stepStarted() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.running++
+}
+
+// stepReturned records that a step running in the background has returned.
+func (g *changeGuard) stepReturned() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.running--
+}
+
+// stepsRunning returns true if any step started in the background has not returned.
+func (g *changeGuard) stepsRunning() bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.running > 0
+}
+
 // Change is a struct that contains the data and functions associated with a single change to a target resource.
 type Change struct {
 	// TargetName is the name or ID of the resource or configuration that is to be changed
-	TargetName string `yaml:"target-name"`
+	TargetName string `json:"target-name" yaml:"target-name"`
 	// Description is a human-readable description of the change
-	Description string `yaml:"description"`
+	Description string `json:"description" yaml:"description"`
 	// applyFunc is the function that will be executed to make the change
 	applyFunc ApplyFunc
 	// revertFunc is the function that will be executed to undo the change
 	revertFunc RevertFunc
 	// TargetObject is supplemental data describing the object that was changed
-	TargetObject interface{} `yaml:"target-object,omitempty"`
+	TargetObject interface{} `json:"target-object,omitempty" yaml:"target-object,omitempty"`
 	// Applied is true if the change was successfully applied at least once
-	Applied bool `yaml:"applied,omitempty"`
+	Applied bool `json:"applied,omitempty" yaml:"applied,omitempty"`
 	// Reverted is true if the change was successfully reverted and not applied again
-	Reverted bool `yaml:"reverted,omitempty"`
+	Reverted bool `json:"reverted,omitempty" yaml:"reverted,omitempty"`
 	// Error is used if any error occurred during the change
-	Error error `yaml:"error,omitempty"`
+	Error error `json:"error,omitempty" yaml:"error,omitempty"`
 	// Allowed may be disabled to prevent the change from being applied
-	Allowed bool `yaml:"allowed,omitempty"`
+	Allowed bool `json:"allowed,omitempty" yaml:"allowed,omitempty"`
+	// Planned is true if the change would have been applied during a dry run
+	Planned bool `json:"planned,omitempty" yaml:"planned,omitempty"`
+	// PlannedInput is the input that would have been passed to the apply function during a dry run
+	PlannedInput interface{} `json:"planned-input,omitempty" yaml:"planned-input,omitempty"`
+	// journal records the change before it is applied and after it is reverted
+	journal *Journal
+	// name is the name of the change in Assessment.Changes, used in the journal
+	name string
+	// requirementId is the requirement ID of the Assessment that owns the change, used in the journal
+	requirementId string
+	// journalId identifies the journal entry for the most recent application of the change
+	journalId string
+	// sequence records the order in which changes were applied, so that they can be reverted in reverse order
+	sequence uint64
+	// dryRun causes Apply to record the change as Planned instead of applying it
+	dryRun bool
+	// observer is notified when the change is applied or reverted
+	observer Observer
+	// guard is shared by the changes of an Assessment while it is run
+	guard *changeGuard
+	// applying is set while the apply function runs without holding the guard
+	applying bool
+}
+
+// changeRecord is the serialized form of a Change, with the Error written as a string.
+type changeRecord struct {
+	TargetName   string      `json:"target-name" yaml:"target-name"`
+	Description  string      `json:"description" yaml:"description"`
+	TargetObject interface{} `json:"target-object,omitempty" yaml:"target-object,omitempty"`
+	Applied      bool        `json:"applied,omitempty" yaml:"applied,omitempty"`
+	Reverted     bool        `json:"reverted,omitempty" yaml:"reverted,omitempty"`
+	Error        string      `json:"error,omitempty" yaml:"error,omitempty"`
+	Allowed      bool        `json:"allowed,omitempty" yaml:"allowed,omitempty"`
+	Planned      bool        `json:"planned,omitempty" yaml:"planned,omitempty"`
+	PlannedInput interface{} `json:"planned-input,omitempty" yaml:"planned-input,omitempty"`
+}
+
+func (c *Change) toRecord() changeRecord {
+	record := changeRecord{
+		TargetName:   c.TargetName,
+		Description:  c.Description,
+		TargetObject: c.TargetObject,
+		Applied:      c.Applied,
+		Reverted:     c.Reverted,
+		Allowed:      c.Allowed,
+		Planned:      c.Planned,
+		PlannedInput: c.PlannedInput,
+	}
+	if c.Error != nil {
+		record.Error = c.Error.Error()
+	}
+	return record
+}
+
+// fromRecord loads the Change from a changeRecord. The apply and revert functions are not restored.
+func (c *Change) fromRecord(record changeRecord) {
+	*c = Change{
+		TargetName:   record.TargetName,
+		Description:  record.Description,
+		TargetObject: record.TargetObject,
+		Applied:      record.Applied,
+		Reverted:     record.Reverted,
+		Allowed:      record.Allowed,
+		Planned:      record.Planned,
+		PlannedInput: record.PlannedInput,
+	}
+	if record.Error != "" {
+		c.Error = errors.New(record.Error)
+	}
+}
+
+// MarshalJSON serializes the Change, writing the Error as a string.
+func (c Change) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.toRecord())
+}
+
+// MarshalYAML serializes the Change, writing the Error as a string.
+func (c Change) MarshalYAML() (interface{}, error) {
+	return c.toRecord(), nil
+}
+
+// UnmarshalJSON loads a Change from previous results. The apply and revert functions are not restored.
+func (c *Change) UnmarshalJSON(data []byte) error {
+	var record changeRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return err
+	}
+	c.fromRecord(record)
+	return nil
+}
+
+// UnmarshalYAML loads a Change from previous results. The apply and revert functions are not restored.
+func (c *Change) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var record changeRecord
+	if err := unmarshal(&record); err != nil {
+		return err
+	}
+	c.fromRecord(record)
+	return nil
 }
 
 // Allow marks the change as allowed to be applied.
@@ -38,8 +180,24 @@ func (c *Change) Allow() {
 }
 
 // Apply the prepared function for the change. It will not apply the change if it has already been applied and not reverted.
-// It will also not apply the change if it is not allowed.
+// It will also not apply the change if it is not allowed. During a dry run, the change is recorded as Planned with the
+// target and input that would have been used, and the apply function is not called.
+// A panic in the apply function is recovered and recorded as the Error of the change.
+// Once the changes of the Assessment that is running the change have been reverted, Apply does nothing. The apply
+// function is called without holding the guard, so that a blocked apply function cannot prevent the changes from
+// being reverted; if they are reverted before it returns, the change is reverted as soon as it has been applied.
 func (c *Change) Apply(targetName string, targetObject interface{}, changeInput interface{}) (applied bool, changeOutput interface{}) {
+	if c.guard != nil {
+		c.guard.mutex.Lock()
+		defer c.guard.mutex.Unlock()
+		if c.guard.reverting || c.applying {
+			return
+		}
+	}
+	if c.dryRun {
+		c.plan(targetName, targetObject, changeInput)
+		return
+	}
 	if !c.Allowed {
 		return
 	}
@@ -52,19 +210,102 @@ func (c *Change) Apply(targetName string, targetObject interface{}, changeInput
 	if c.Applied && !c.Reverted {
 		return true, nil
 	}
+	if c.journal != nil {
+		c.journalId, err = c.journal.recordApply(c.name, c, targetName, targetObject)
+		if err != nil {
+			c.Error = err
+			return
+		}
+	}
 	c.TargetName = targetName
 	c.TargetObject = targetObject
-	changeOutput, err = c.applyFunc(changeInput)
+	changeOutput, err = c.unguardedApply(changeInput)
 	if err != nil {
+		if c.journal != nil {
+			c.recordJournal(JournalFailed)
+		}
 		return false, changeOutput
 	}
 	c.Applied = true
 	c.Reverted = false
+	c.sequence = changeSequence.Add(1)
+	if c.observer != nil {
+		c.observer.OnChangeApplied(c)
+	}
+	if c.guard != nil && c.guard.reverting {
+		c.revert(nil)
+	}
 	return true, changeOutput
 }
 
+// unguardedApply marks the change as applying and releases the guard while the apply function runs.
+func (c *Change) unguardedApply(changeInput interface{}) (changeOutput interface{}, err error) {
+	if c.guard == nil {
+		return c.callApply(changeInput)
+	}
+	c.applying = true
+	c.guard.mutex.Unlock()
+	defer func() {
+		c.guard.mutex.Lock()
+		c.applying = false
+	}()
+	return c.callApply(changeInput)
+}
+
+// callApply runs the apply function, recording a recovered panic as the Error of the change.
+func (c *Change) callApply(changeInput interface{}) (changeOutput interface{}, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("apply function for change %s failed: %w", c.TargetName, panicError(recovered))
+			c.Error = err
+		}
+	}()
+	return c.applyFunc(changeInput)
+}
+
+// callRevert runs the revert function, converting a recovered panic into an error.
+func (c *Change) callRevert(data interface{}) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("revert function for change %s failed: %w", c.TargetName, panicError(recovered))
+		}
+	}()
+	return c.revertFunc(data)
+}
+
+// recordJournal closes the journal entry for the most recent application of the change.
+// The change has already been applied or reverted, so a journal error is logged rather than recorded on the change.
+func (c *Change) recordJournal(event string) {
+	if err := c.journal.record(c.journalId, event, c.name, c); err != nil {
+		log.Printf("change %s: %v", c.name, err)
+	}
+}
+
+// plan records the change as Planned without calling the apply function.
+func (c *Change) plan(targetName string, targetObject interface{}, changeInput interface{}) {
+	err := c.precheck()
+	if err != nil {
+		c.Error = err
+		return
+	}
+	c.TargetName = targetName
+	c.TargetObject = targetObject
+	c.PlannedInput = changeInput
+	c.Planned = true
+}
+
 // Revert the change by executing the revert function. It will not revert the change if it has not been applied.
+// A panic in the revert function is recovered and recorded as the Error of the change.
 func (c *Change) Revert(data interface{}) {
+	if c.guard != nil {
+		c.guard.mutex.Lock()
+		defer c.guard.mutex.Unlock()
+	}
+	c.revert(data)
+}
+
+// revert reverts the change without acquiring the guard.
+func (c *Change) revert(data interface{}) {
 	err := c.precheck()
 	if err != nil {
 		c.Error = err
@@ -73,12 +314,18 @@ func (c *Change) Revert(data interface{}) {
 	if !c.Applied {
 		return
 	}
-	err = c.revertFunc(data)
+	err = c.callRevert(data)
 	if err != nil {
 		c.Error = err
 		return
 	}
 	c.Reverted = true
+	if c.journal != nil {
+		c.recordJournal(JournalRevert)
+	}
+	if c.observer != nil {
+		c.observer.OnChangeReverted(c)
+	}
 }
 
 // precheck verifies that the applyFunc and revertFunc are defined for the change.
@@ -1,6 +1,12 @@
 package layer4
 
-import "testing"
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+)
 
 func changesTestData() []struct {
 	testName string
@@ -133,3 +139,34 @@ func TestRevert(t *testing.T) {
 		})
 	}
 }
+
+// TestChangeSerialization ensures that Change.Error survives a round trip as a string
+func TestChangeSerialization(t *testing.T) {
+	change := badApplyChange()
+	change.Error = errors.New("failed to apply change")
+
+	jsonData, err := json.Marshal(&change)
+	if err != nil {
+		t.Fatalf("Unexpected error marshaling JSON: %v", err)
+	}
+	yamlData, err := yaml.Marshal(&change)
+	if err != nil {
+		t.Fatalf("Unexpected error marshaling YAML: %v", err)
+	}
+
+	var fromJSON, fromYAML Change
+	if err := json.Unmarshal(jsonData, &fromJSON); err != nil {
+		t.Fatalf("Unexpected error unmarshaling JSON: %v", err)
+	}
+	if err := yaml.Unmarshal(yamlData, &fromYAML); err != nil {
+		t.Fatalf("Unexpected error unmarshaling YAML: %v", err)
+	}
+	for name, loaded := range map[string]Change{"json": fromJSON, "yaml": fromYAML} {
+		if loaded.Error == nil || loaded.Error.Error() != change.Error.Error() {
+			t.Errorf("Expected %s Error to be %q, got %v", name, change.Error, loaded.Error)
+		}
+		if loaded.TargetName != change.TargetName || loaded.Description != change.Description {
+			t.Errorf("Expected %s TargetName and Description to match, got %q and %q", name, loaded.TargetName, loaded.Description)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package layer4
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// CleanupCoordinator tracks every active ControlEvaluation with pending Changes so that their changes can be
+// reverted if the process is interrupted or a watched context is cancelled. A single coordinator may be shared
+// by any number of concurrent evaluations.
+type CleanupCoordinator struct {
+	mutex         sync.Mutex
+	active        map[*ControlEvaluation]struct{}
+	handleSignals bool
+	listening     bool
+	signals       chan os.Signal
+	exit          func(code int)
+	// releasing counts the evaluations that are being reverted by release outside of the mutex
+	releasing sync.WaitGroup
+}
+
+// CoordinatorOption defines an option to tune the behavior of a CleanupCoordinator.
+type CoordinatorOption func(c *CleanupCoordinator)
+
+// WithoutSignalHandling is a CoordinatorOption that prevents the CleanupCoordinator from listening for interrupts.
+// Programs that embed this library should use it and call Watch or RevertAll themselves.
+func WithoutSignalHandling() CoordinatorOption {
+	return func(c *CleanupCoordinator) {
+		c.handleSignals = false
+	}
+}
+
+// WithExitFunc is a CoordinatorOption that replaces os.Exit as the function called after an interrupt is handled.
+func WithExitFunc(exit func(code int)) CoordinatorOption {
+	return func(c *CleanupCoordinator) {
+		c.exit = exit
+	}
+}
+
+var (
+	defaultCoordinator     *CleanupCoordinator
+	defaultCoordinatorOnce sync.Once
+)
+
+// DefaultCoordinator returns the process-wide CleanupCoordinator used when no WithCleanupCoordinator
+// option is provided. It handles interrupts unless StopSignalHandling is called.
+func DefaultCoordinator() *CleanupCoordinator {
+	defaultCoordinatorOnce.Do(func() {
+		defaultCoordinator = NewCleanupCoordinator()
+	})
+	return defaultCoordinator
+}
+
+// NewCleanupCoordinator creates a CleanupCoordinator. By default it listens for interrupts while any evaluation
+// with pending Changes is tracked, reverts all tracked changes, and exits the process. While nothing is tracked,
+// interrupts are handled by the default behavior.
+func NewCleanupCoordinator(opts ...CoordinatorOption) *CleanupCoordinator {
+	c := &CleanupCoordinator{
+		active:        make(map[*ControlEvaluation]struct{}),
+		handleSignals: true,
+		exit:          os.Exit,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// StopSignalHandling stops listening for interrupts. Tracked evaluations are still reverted when they complete.
+func (c *CleanupCoordinator) StopSignalHandling() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.handleSignals = false
+	c.stopListening()
+}
+
+// Watch reverts all tracked changes when the context is done. The returned status is sent on the channel,
+// which is closed afterward.
+func (c *CleanupCoordinator) Watch(ctx context.Context) <-chan int {
+	status := make(chan int, 1)
+	go func() {
+		defer close(status)
+		<-ctx.Done()
+		status <- c.RevertAll()
+	}()
+	return status
+}
+
+// RevertAll reverts the changes of every tracked evaluation and stops tracking them. Evaluations that are still
+// running can no longer apply changes, and are reverted again when they complete. Evaluations that completed and
+// are already being reverted are waited for. Each change that could not be reverted is logged. It returns a
+// non-zero exit status if any change of a tracked evaluation could not be reverted.
+func (c *CleanupCoordinator) RevertAll() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	status := 0
+	for evaluation := range c.active {
+		if err := evaluation.Cleanup(); err != nil {
+			log.Print(err)
+		}
+		if evaluation.CorruptedState {
+			status = 1
+		}
+		delete(c.active, evaluation)
+	}
+	c.stopListening()
+	c.releasing.Wait()
+	return status
+}
+
+// track registers an evaluation that is about to run, starting the signal listener if required.
+func (c *CleanupCoordinator) track(evaluation *ControlEvaluation) {
+	if !evaluation.hasChanges() {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.active[evaluation] = struct{}{}
+	if c.handleSignals && !c.listening {
+		c.listen()
+	}
+}
+
+// release stops tracking a completed evaluation and reverts its changes, returning an error that describes each
+// change that could not be reverted. The changes are reverted without holding the mutex, so that a slow revert does
+// not delay other evaluations. Evaluations that were reverted by RevertAll are cleaned up again, which only reverts
+// changes that remain applied.
+func (c *CleanupCoordinator) release(evaluation *ControlEvaluation) error {
+	c.mutex.Lock()
+	delete(c.active, evaluation)
+	if len(c.active) == 0 {
+		c.stopListening()
+	}
+	c.releasing.Add(1)
+	c.mutex.Unlock()
+	defer c.releasing.Done()
+	return evaluation.Cleanup()
+}
+
+// stopListening stops the signal listener, if it is running. The caller must hold the mutex.
+func (c *CleanupCoordinator) stopListening() {
+	if !c.listening {
+		return
+	}
+	signal.Stop(c.signals)
+	close(c.signals)
+	c.listening = false
+}
+
+// listen creates a single 'listener' on a new goroutine which will notify the program if it receives an interrupt
+// from the operating system. The listener stops after the first interrupt, so that later interrupts are handled by
+// the default behavior or by a new listener. The caller must hold the mutex.
+func (c *CleanupCoordinator) listen() {
+	c.signals = make(chan os.Signal, 1)
+	c.listening = true
+	signal.Notify(c.signals, os.Interrupt, syscall.SIGTERM)
+	go func(signals chan os.Signal) {
+		if _, ok := <-signals; !ok {
+			return
+		}
+		c.mutex.Lock()
+		signal.Stop(signals)
+		if c.signals == signals {
+			c.listening = false
+		}
+		c.mutex.Unlock()
+		log.Print("\n*****\nUnexpected termination. Attempting to revert changes made by active evaluations. Do not interrupt this process.\n*****\n")
+		c.exit(c.RevertAll())
+	}(c.signals)
+}
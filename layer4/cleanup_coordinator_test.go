@@ -0,0 +1,227 @@
+package layer4
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func appliedChangeEvaluation(change *Change) *ControlEvaluation {
+	return &ControlEvaluation{
+		Assessments: []*Assessment{{
+			RequirementId: "coordinator",
+			Changes:       map[string]*Change{change.TargetName: change},
+		}},
+	}
+}
+
+func appliedBadRevertChangePtr() *Change {
+	c := badRevertChangePtr()
+	c.Applied = true
+	return c
+}
+
+func TestCleanupCoordinatorRevertAll(t *testing.T) {
+	tests := []struct {
+		testName       string
+		change         *Change
+		expectedStatus int
+	}{
+		{
+			testName:       "Successful revert returns zero status",
+			change:         goodNotRevertedChangePtr(),
+			expectedStatus: 0,
+		},
+		{
+			testName:       "Failed revert returns non-zero status",
+			change:         appliedBadRevertChangePtr(),
+			expectedStatus: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			coordinator := NewCleanupCoordinator(WithoutSignalHandling())
+			evaluation := appliedChangeEvaluation(test.change)
+			coordinator.track(evaluation)
+
+			status := coordinator.RevertAll()
+			if status != test.expectedStatus {
+				t.Errorf("Expected status %d, got %d", test.expectedStatus, status)
+			}
+			if len(coordinator.active) != 0 {
+				t.Errorf("Expected no tracked evaluations after RevertAll, got %d", len(coordinator.active))
+			}
+			if test.expectedStatus == 0 && !test.change.Reverted {
+				t.Errorf("Expected change to be reverted")
+			}
+		})
+	}
+}
+
+// TestCleanupCoordinatorRelease ensures that release reverts changes that remain applied after RevertAll
+func TestCleanupCoordinatorRelease(t *testing.T) {
+	coordinator := NewCleanupCoordinator(WithoutSignalHandling())
+	change := goodNotRevertedChangePtr()
+	evaluation := appliedChangeEvaluation(change)
+	coordinator.track(evaluation)
+	coordinator.RevertAll()
+
+	change.Reverted = false
+	coordinator.release(evaluation)
+	if !change.Reverted {
+		t.Errorf("Expected release to revert an evaluation that is no longer tracked")
+	}
+}
+
+// TestCleanupCoordinatorReleaseUnlocked ensures that an evaluation being released does not prevent other evaluations
+// from being tracked
+func TestCleanupCoordinatorReleaseUnlocked(t *testing.T) {
+	coordinator := NewCleanupCoordinator(WithoutSignalHandling())
+	unblock := make(chan struct{})
+	change := goodNotRevertedChangePtr()
+	change.revertFunc = func(interface{}) error {
+		<-unblock
+		return nil
+	}
+	evaluation := appliedChangeEvaluation(change)
+	coordinator.track(evaluation)
+	released := make(chan error)
+	go func() {
+		released <- coordinator.release(evaluation)
+	}()
+
+	tracked := make(chan struct{})
+	go func() {
+		coordinator.track(appliedChangeEvaluation(goodNotRevertedChangePtr()))
+		close(tracked)
+	}()
+	select {
+	case <-tracked:
+	case <-time.After(time.Second):
+		t.Fatal("Expected track to return while another evaluation was being released")
+	}
+	close(unblock)
+	if err := <-released; err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+// TestCleanupCoordinatorRevertAllRunning ensures that an evaluation reverted by RevertAll while a step is running
+// cannot apply further changes
+func TestCleanupCoordinatorRevertAllRunning(t *testing.T) {
+	coordinator := NewCleanupCoordinator(WithoutSignalHandling())
+	paused := make(chan struct{})
+	var secondApplied bool
+	step := func(_ interface{}, changes map[string]*Change) (Result, string) {
+		changes["first"].Apply("first", nil, nil)
+		paused <- struct{}{}
+		<-paused
+		secondApplied, _ = changes["second"].Apply("second", nil, nil)
+		return Passed, ""
+	}
+	first, second := pendingChangePtr(), pendingChangePtr()
+	assessment := passingAssessmentPtr()
+	assessment.Steps = []AssessmentStep{step}
+	assessment.Changes = map[string]*Change{"first": first, "second": second}
+	evaluation := &ControlEvaluation{Assessments: []*Assessment{assessment}}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		evaluation.EvaluateContext(context.Background(), nil, testingApplicability, true, WithCleanupCoordinator(coordinator))
+	}()
+	<-paused
+	if status := coordinator.RevertAll(); status != 0 {
+		t.Errorf("Expected status 0, got %d", status)
+	}
+	paused <- struct{}{}
+	<-done
+
+	if !first.Reverted {
+		t.Errorf("Expected the first change to be reverted")
+	}
+	if secondApplied || second.Applied {
+		t.Errorf("Expected the second change not to be applied after RevertAll")
+	}
+	if evaluation.CorruptedState {
+		t.Errorf("Expected CorruptedState to be false")
+	}
+}
+
+func TestCleanupCoordinatorWatch(t *testing.T) {
+	coordinator := NewCleanupCoordinator(WithoutSignalHandling())
+	change := goodNotRevertedChangePtr()
+	coordinator.track(appliedChangeEvaluation(change))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	status := coordinator.Watch(ctx)
+	cancel()
+
+	select {
+	case code := <-status:
+		if code != 0 {
+			t.Errorf("Expected status 0, got %d", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Watch to revert changes after the context was cancelled")
+	}
+	if !change.Reverted {
+		t.Errorf("Expected change to be reverted")
+	}
+}
+
+func TestCleanupCoordinatorSignal(t *testing.T) {
+	exitCodes := make(chan int, 1)
+	coordinator := NewCleanupCoordinator(WithExitFunc(func(code int) { exitCodes <- code }))
+	defer coordinator.StopSignalHandling()
+	coordinator.track(appliedChangeEvaluation(appliedBadRevertChangePtr()))
+
+	coordinator.signals <- os.Interrupt
+	select {
+	case code := <-exitCodes:
+		if code != 1 {
+			t.Errorf("Expected exit code 1 after a failed revert, got %d", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the coordinator to exit after an interrupt")
+	}
+	coordinator.mutex.Lock()
+	defer coordinator.mutex.Unlock()
+	if coordinator.listening {
+		t.Errorf("Expected the coordinator to stop listening after handling an interrupt")
+	}
+}
+
+// TestCleanupCoordinatorListening ensures that interrupts are only handled while an evaluation is tracked
+func TestCleanupCoordinatorListening(t *testing.T) {
+	coordinator := NewCleanupCoordinator(WithExitFunc(func(int) {}))
+	defer coordinator.StopSignalHandling()
+	first := appliedChangeEvaluation(goodNotRevertedChangePtr())
+	second := appliedChangeEvaluation(goodNotRevertedChangePtr())
+	coordinator.track(first)
+	coordinator.track(second)
+
+	coordinator.release(first)
+	if !coordinator.listening {
+		t.Errorf("Expected the coordinator to listen for interrupts while an evaluation is tracked")
+	}
+	coordinator.release(second)
+	if coordinator.listening {
+		t.Errorf("Expected the coordinator to stop listening for interrupts once nothing is tracked")
+	}
+	coordinator.track(first)
+	if !coordinator.listening {
+		t.Errorf("Expected the coordinator to listen for interrupts again once an evaluation is tracked")
+	}
+}
+
+func TestCleanupCoordinatorStopSignalHandling(t *testing.T) {
+	coordinator := NewCleanupCoordinator()
+	coordinator.StopSignalHandling()
+	coordinator.track(appliedChangeEvaluation(goodNotRevertedChangePtr()))
+
+	if coordinator.listening {
+		t.Errorf("Expected coordinator not to listen for interrupts after StopSignalHandling")
+	}
+}
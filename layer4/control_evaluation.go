@@ -1,26 +1,82 @@
 package layer4
 
 import (
-	"log"
-	"os"
-	"os/signal"
-	"syscall"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ossf/gemara/layer2"
+	"github.com/ossf/gemara/layer3"
 )
 
 // ControlEvaluation is a struct that contains all assessment results, organized by name.
 type ControlEvaluation struct {
 	// Name is the name of the control being evaluated
-	Name string `yaml:"name"`
+	Name string `json:"name" yaml:"name"`
 	// ControlID is the unique identifier for the control being evaluated
-	ControlID string `yaml:"control-id"`
+	ControlID string `json:"control-id" yaml:"control-id"`
 	// Result is the overall result of the control evaluation
-	Result Result `yaml:"result"`
-	// Message is the human-readable result of the final assessment to run in this evaluation
-	Message string `yaml:"message"`
+	Result Result `json:"result" yaml:"result"`
+	// Message is the human-readable result of the final assessment to run in this evaluation,
+	// followed by a description of any changes that could not be reverted
+	Message string `json:"message" yaml:"message"`
 	// CorruptedState is true if the control evaluation was interrupted and changes were not reverted
-	CorruptedState bool `yaml:"corrupted-state"`
+	CorruptedState bool `json:"corrupted-state" yaml:"corrupted-state"`
 	// Assessments is a map of pointers to Assessment objects to establish idempotency
-	Assessments []*Assessment `yaml:"assessments"`
+	Assessments []*Assessment `json:"assessments" yaml:"assessments"`
+}
+
+// NewControlEvaluation creates a ControlEvaluation for the control with the provided ID in a Layer 2 Catalog.
+// An Assessment is created for each of the control's assessment requirements, leaving only the steps to be
+// added with AddStep.
+func NewControlEvaluation(catalog *layer2.Catalog, controlId string) (*ControlEvaluation, error) {
+	for _, family := range catalog.ControlFamilies {
+		for _, control := range family.Controls {
+			if control.Id == controlId {
+				return newControlEvaluation(control), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("control %s not found in catalog %s", controlId, catalog.Metadata.Id)
+}
+
+// newControlEvaluation creates a ControlEvaluation with an Assessment for each of the control's assessment requirements.
+func newControlEvaluation(control layer2.Control) *ControlEvaluation {
+	c := &ControlEvaluation{
+		Name:      control.Title,
+		ControlID: control.Id,
+	}
+	for _, requirement := range control.AssessmentRequirements {
+		c.Assessments = append(c.Assessments, &Assessment{
+			RequirementId:  requirement.Id,
+			Description:    requirement.Text,
+			Applicability:  append([]string(nil), requirement.Applicability...),
+			Recommendation: requirement.Recommendation,
+			Result:         NotRun,
+		})
+	}
+	return c
+}
+
+// AddStep queues a new step in the Assessment for the requirement with the provided ID.
+func (c *ControlEvaluation) AddStep(requirementId string, step AssessmentStep) error {
+	assessment := c.GetAssessment(requirementId)
+	if assessment == nil {
+		return fmt.Errorf("no assessment found for requirement %s in control %s", requirementId, c.ControlID)
+	}
+	assessment.AddStep(step)
+	return nil
+}
+
+// GetAssessment returns the Assessment for the requirement with the provided ID, or nil if it does not exist.
+func (c *ControlEvaluation) GetAssessment(requirementId string) *Assessment {
+	for _, assessment := range c.Assessments {
+		if assessment.RequirementId == requirementId {
+			return assessment
+		}
+	}
+	return nil
 }
 
 // AddAssessment creates a new Assessment object and adds it to the ControlEvaluation.
@@ -36,55 +92,148 @@ func (c *ControlEvaluation) AddAssessment(requirementId string, description stri
 
 // Evaluate runs each step in each assessment, updating the relevant fields on the control evaluation.
 // It will halt if a step returns a failed result. The targetData is the data that the assessment will be run against.
-// The userApplicability is a slice of strings that determine when the assessment is applicable. The changesAllowed
-// determines whether the assessment is allowed to execute its changes.
+// The userApplicability is a slice of strings that determine when the assessment is applicable. Assessments that do not
+// match the userApplicability are marked as NotApplicable instead of being run. The changesAllowed determines whether
+// the assessment is allowed to execute its changes.
 func (c *ControlEvaluation) Evaluate(targetData interface{}, userApplicability []string, changesAllowed bool) {
+	c.EvaluateContext(context.Background(), targetData, userApplicability, changesAllowed)
+}
+
+// EvaluateContext runs each assessment in the same way as Evaluate, passing the context and options to
+// Assessment.RunContext. If the context is done, the remaining assessments are not run, the result is
+// recorded as Unknown, and all changes are reverted. With WithContinueOnFailure, the remaining assessments are
+// run after a Failed assessment.
+func (c *ControlEvaluation) EvaluateContext(ctx context.Context, targetData interface{}, userApplicability []string, changesAllowed bool, opts ...RunOption) {
 	if len(c.Assessments) == 0 {
 		c.Result = NeedsReview
 		return
 	}
-	c.closeHandler()
+	c.evaluate(ctx, targetData, userApplicability, changesAllowed, opts)
+}
+
+// evaluate runs each assessment while the evaluation is tracked by the CleanupCoordinator from the options,
+// which reverts all changes once the assessments are complete. Changes that could not be reverted are described
+// in the Message.
+func (c *ControlEvaluation) evaluate(ctx context.Context, targetData interface{}, userApplicability []string, changesAllowed bool, opts []RunOption) {
+	if len(c.Assessments) == 0 {
+		c.Result = NeedsReview
+		return
+	}
+	options := newRunOpts(opts)
+	options.observer.OnEvaluationStart(c)
+	defer options.observer.OnEvaluationEnd(c)
 	for _, assessment := range c.Assessments {
-		var applicable bool
-		for _, aa := range assessment.Applicability {
-			for _, ua := range userApplicability {
-				if aa == ua {
-					applicable = true
-					break
-				}
+		assessment.guardChanges()
+	}
+	options.coordinator.track(c)
+	defer func() {
+		if err := options.coordinator.release(c); err != nil {
+			message := fmt.Sprintf("changes could not be reverted: %s", err)
+			if c.Message != "" {
+				message = c.Message + "; " + message
 			}
+			c.Message = message
 		}
-		if applicable {
-			result := assessment.Run(targetData, changesAllowed)
-			c.Result = UpdateAggregateResult(c.Result, result)
+	}()
+	for _, assessment := range c.Assessments {
+		if ctx.Err() != nil {
+			c.Result = UpdateAggregateResult(c.Result, Unknown)
+			c.Message = fmt.Sprintf("evaluation did not complete: %s", ctx.Err())
+			break
+		}
+		if assessment.isApplicable(userApplicability) {
+			assessment.RunContext(ctx, targetData, changesAllowed, opts...)
+		} else if assessment.Result != NotApplicable {
+			assessment.markNotApplicable(fmt.Sprintf(
+				"assessment applicability (%s) does not match the requested applicability (%s)",
+				strings.Join(assessment.Applicability, ", "), strings.Join(userApplicability, ", "),
+			))
+		}
+		previous := c.Result
+		c.Result = UpdateAggregateResult(c.Result, assessment.Result)
+		// Only report a NotApplicable message if no other assessment has produced a result,
+		// and keep the message of a failed assessment when later assessments are run after the failure
+		if (assessment.Result != NotApplicable || c.Result == NotApplicable) && (previous != Failed || assessment.Result == Failed) {
 			c.Message = assessment.Message
-			if c.Result == Failed {
-				break
-			}
+		}
+		if c.Result == Failed && !options.continueOnFailure {
+			break
 		}
 	}
-	c.Cleanup()
 }
 
-// Cleanup reverts all changes made by the ControlEvaluation.
-func (c *ControlEvaluation) Cleanup() {
+// PolicyTarget describes the target of an evaluation in terms of the scope of a Layer 3 policy.
+type PolicyTarget struct {
+	// Policy is the Layer 3 policy that determines which assessments are in scope
+	Policy *layer3.PolicyDocument
+	// ReferenceId identifies the policy's control reference for the catalog being evaluated
+	ReferenceId string
+	// Scope lists the boundaries, technologies and providers that describe the target
+	Scope layer3.Scope
+}
+
+// EvaluateInScope runs the ControlEvaluation in the same way as Evaluate, after comparing the target with the scope
+// of the policy. If the target is out of scope, or the control or requirement was excluded by the policy, the
+// assessment is marked as NotApplicable with a message explaining why, and its steps are not run.
+// An error is returned, and nothing is run, if the target has no policy.
+func (c *ControlEvaluation) EvaluateInScope(targetData interface{}, target PolicyTarget, userApplicability []string, changesAllowed bool) error {
+	return c.EvaluateInScopeContext(context.Background(), targetData, target, userApplicability, changesAllowed)
+}
+
+// EvaluateInScopeContext compares the target with the scope of the policy in the same way as EvaluateInScope,
+// then runs the assessments that are in scope in the same way as EvaluateContext, with the context and options.
+func (c *ControlEvaluation) EvaluateInScopeContext(ctx context.Context, targetData interface{}, target PolicyTarget, userApplicability []string, changesAllowed bool, opts ...RunOption) error {
+	if target.Policy == nil {
+		return fmt.Errorf("policy target for control %s has no policy", c.ControlID)
+	}
+	inScope, reason := target.Policy.TargetInScope(target.ReferenceId, target.Scope)
+	controlExcluded, controlRationale := target.Policy.Excluded(target.ReferenceId, c.ControlID)
 	for _, assessment := range c.Assessments {
-		corrupted := assessment.RevertChanges()
-		if corrupted {
-			c.CorruptedState = true
+		if !inScope {
+			assessment.markNotApplicable(reason)
+			continue
+		}
+		if controlExcluded {
+			assessment.markNotApplicable(fmt.Sprintf("control %s was excluded by policy %s: %s", c.ControlID, target.Policy.Metadata.Id, controlRationale))
+			continue
+		}
+		if excluded, rationale := target.Policy.Excluded(target.ReferenceId, assessment.RequirementId); excluded {
+			assessment.markNotApplicable(fmt.Sprintf("requirement %s was excluded by policy %s: %s", assessment.RequirementId, target.Policy.Metadata.Id, rationale))
 		}
 	}
+	c.EvaluateContext(ctx, targetData, userApplicability, changesAllowed, opts...)
+	return nil
 }
 
-// closeHandler creates a 'listener' on a new goroutine which will notify the program if it receives an interrupt from the operating system.
-// If an interrupt is received, this will attempt to revert any changes made by the terminated ControlEvaluation.
-func (c *ControlEvaluation) closeHandler() {
-	channel := make(chan os.Signal, 1)
-	signal.Notify(channel, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-channel
-		log.Print("\n*****\nUnexpected termination. Attempting to revert changes made by the active ControlEvaluation. Do not interrupt this process.\n*****\n")
-		c.Cleanup()
-		os.Exit(0)
-	}()
+// hasChanges returns true if any assessment in the ControlEvaluation has declared a Change.
+func (c *ControlEvaluation) hasChanges() bool {
+	for _, assessment := range c.Assessments {
+		if len(assessment.Changes) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// PlannedChanges returns the changes that were recorded by every assessment during a dry run.
+func (c *ControlEvaluation) PlannedChanges() []*Change {
+	var planned []*Change
+	for _, assessment := range c.Assessments {
+		planned = append(planned, assessment.PlannedChanges()...)
+	}
+	return planned
+}
+
+// Cleanup reverts all changes made by the ControlEvaluation, in the reverse of the order they were applied.
+// Every change is attempted, and the returned error describes each change that could not be reverted.
+func (c *ControlEvaluation) Cleanup() error {
+	var errs []error
+	for i := len(c.Assessments) - 1; i >= 0; i-- {
+		assessment := c.Assessments[i]
+		if assessmentErrs := assessment.revertChanges(); len(assessmentErrs) > 0 {
+			c.CorruptedState = true
+			errs = append(errs, assessmentErrs...)
+		}
+	}
+	return errors.Join(errs...)
 }
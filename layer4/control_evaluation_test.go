@@ -1,6 +1,13 @@
 package layer4
 
-import "testing"
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ossf/gemara/layer3"
+)
 
 var controlEvaluationTestData = []struct {
 	testName          string
@@ -150,3 +157,435 @@ func TestAddAssesment(t *testing.T) {
 	}
 
 }
+
+func TestNewControlEvaluation(t *testing.T) {
+	tests := []struct {
+		testName            string
+		controlId           string
+		expectedAssessments int
+		expectedErr         bool
+	}{
+		{
+			testName:            "Control with two assessment requirements",
+			controlId:           "AC-01",
+			expectedAssessments: 2,
+		},
+		{
+			testName:            "Control without assessment requirements",
+			controlId:           "AC-02",
+			expectedAssessments: 0,
+		},
+		{
+			testName:    "Control missing from catalog",
+			controlId:   "AC-99",
+			expectedErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			c, err := NewControlEvaluation(testCatalog(), test.controlId)
+			if test.expectedErr {
+				if err == nil {
+					t.Errorf("Expected error, but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Did not expect error, but got '%s'", err.Error())
+			}
+			if c.ControlID != test.controlId {
+				t.Errorf("Expected ControlID to be %s, but it was %s", test.controlId, c.ControlID)
+			}
+			if len(c.Assessments) != test.expectedAssessments {
+				t.Errorf("Expected %d Assessments, but got %d", test.expectedAssessments, len(c.Assessments))
+			}
+		})
+	}
+}
+
+func TestNewControlEvaluationFields(t *testing.T) {
+	catalog := testCatalog()
+	c, err := NewControlEvaluation(catalog, "AC-01")
+	if err != nil {
+		t.Fatalf("Did not expect error, but got '%s'", err.Error())
+	}
+	requirement := catalog.ControlFamilies[0].Controls[0].AssessmentRequirements[0]
+	assessment := c.GetAssessment(requirement.Id)
+	if assessment == nil {
+		t.Fatalf("Expected an Assessment for %s, but found none", requirement.Id)
+	}
+	if c.Name != "Multi-factor authentication" {
+		t.Errorf("Expected Name to be the control title, but it was %q", c.Name)
+	}
+	if assessment.Description != requirement.Text {
+		t.Errorf("Expected Description to be the requirement text, but it was %q", assessment.Description)
+	}
+	if assessment.Recommendation != requirement.Recommendation {
+		t.Errorf("Expected Recommendation to be %q, but it was %q", requirement.Recommendation, assessment.Recommendation)
+	}
+	if len(assessment.Applicability) != 1 || assessment.Applicability[0] != requirement.Applicability[0] {
+		t.Errorf("Expected Applicability to be %v, but it was %v", requirement.Applicability, assessment.Applicability)
+	}
+}
+
+func TestControlEvaluationAddStep(t *testing.T) {
+	c, err := NewControlEvaluation(testCatalog(), "AC-01")
+	if err != nil {
+		t.Fatalf("Did not expect error, but got '%s'", err.Error())
+	}
+	if err := c.AddStep("AC-01.01", passingAssessmentStep); err != nil {
+		t.Errorf("Did not expect error, but got '%s'", err.Error())
+	}
+	if err := c.AddStep("AC-99.01", passingAssessmentStep); err == nil {
+		t.Errorf("Expected error for unknown requirement, but got none")
+	}
+	if len(c.GetAssessment("AC-01.01").Steps) != 1 {
+		t.Errorf("Expected 1 step to be added, but got %d", len(c.GetAssessment("AC-01.01").Steps))
+	}
+
+	c.Evaluate(nil, testingApplicability, false)
+	if c.Result != Passed {
+		t.Errorf("Expected Result to be Passed, but it was %v", c.Result)
+	}
+}
+
+func TestEvaluateInScope(t *testing.T) {
+	policy := &layer3.PolicyDocument{
+		Metadata: layer3.Metadata{Id: "test-policy"},
+		Scope: layer3.Scope{
+			Technologies: []string{"Source Control"},
+		},
+		ControlReferences: []layer3.Mapping{
+			{
+				ReferenceId: "test-catalog",
+				OutOfScope: layer3.Scope{
+					Providers: []string{"Self-hosted"},
+				},
+				AssessmentRequirementModifications: []layer3.AssessmentRequirementModifier{
+					{
+						TargetId:              "AC-01.02",
+						ModType:               layer3.Exclude,
+						ModificationRationale: "Second factors are managed by the identity provider",
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		testName          string
+		target            PolicyTarget
+		expectedResults   map[string]Result
+		expectedExecution map[string]bool
+	}{
+		{
+			testName: "Target in scope with an excluded requirement",
+			target: PolicyTarget{
+				Policy:      policy,
+				ReferenceId: "test-catalog",
+				Scope:       layer3.Scope{Technologies: []string{"Source Control"}},
+			},
+			expectedResults:   map[string]Result{"AC-01.01": Passed, "AC-01.02": NotApplicable},
+			expectedExecution: map[string]bool{"AC-01.01": true, "AC-01.02": false},
+		},
+		{
+			testName: "Target out of scope",
+			target: PolicyTarget{
+				Policy:      policy,
+				ReferenceId: "test-catalog",
+				Scope:       layer3.Scope{Providers: []string{"Self-hosted"}},
+			},
+			expectedResults:   map[string]Result{"AC-01.01": NotApplicable, "AC-01.02": NotApplicable},
+			expectedExecution: map[string]bool{"AC-01.01": false, "AC-01.02": false},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			c, err := NewControlEvaluation(testCatalog(), "AC-01")
+			if err != nil {
+				t.Fatalf("Did not expect error, but got '%s'", err.Error())
+			}
+			for _, assessment := range c.Assessments {
+				assessment.AddStep(passingAssessmentStep)
+			}
+			if err := c.EvaluateInScope(nil, test.target, append(testingApplicability, "other-applicability"), false); err != nil {
+				t.Fatalf("Did not expect error, but got '%s'", err.Error())
+			}
+
+			for _, assessment := range c.Assessments {
+				if assessment.Result != test.expectedResults[assessment.RequirementId] {
+					t.Errorf("Expected %s Result to be %v, but it was %v", assessment.RequirementId, test.expectedResults[assessment.RequirementId], assessment.Result)
+				}
+				if (assessment.StepsExecuted > 0) != test.expectedExecution[assessment.RequirementId] {
+					t.Errorf("Expected %s steps to be executed: %v, but %d steps were executed", assessment.RequirementId, test.expectedExecution[assessment.RequirementId], assessment.StepsExecuted)
+				}
+				if assessment.Result == NotApplicable && (assessment.Message == "" || assessment.Start == "") {
+					t.Errorf("Expected %s to record a message and start time, got message=%q, start=%q", assessment.RequirementId, assessment.Message, assessment.Start)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateInScopeContext(t *testing.T) {
+	c, err := NewControlEvaluation(testCatalog(), "AC-01")
+	if err != nil {
+		t.Fatalf("Did not expect error, but got '%s'", err.Error())
+	}
+	if err := c.EvaluateInScope(nil, PolicyTarget{ReferenceId: "test-catalog"}, testingApplicability, false); err == nil {
+		t.Error("Expected an error for a target without a policy")
+	}
+	if c.Assessments[0].StepsExecuted > 0 || c.Result != NotRun {
+		t.Errorf("Expected nothing to run for a target without a policy, got Result %v", c.Result)
+	}
+
+	hangingStep := func(interface{}, map[string]*Change) (Result, string) {
+		time.Sleep(200 * time.Millisecond)
+		return Passed, ""
+	}
+	for _, assessment := range c.Assessments {
+		assessment.AddStep(hangingStep)
+	}
+	target := PolicyTarget{
+		Policy: &layer3.PolicyDocument{
+			Metadata:          layer3.Metadata{Id: "test-policy"},
+			ControlReferences: []layer3.Mapping{{ReferenceId: "test-catalog"}},
+		},
+		ReferenceId: "test-catalog",
+	}
+	err = c.EvaluateInScopeContext(context.Background(), nil, target, append(testingApplicability, "other-applicability"), false, WithStepTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Did not expect error, but got '%s'", err.Error())
+	}
+	if c.Result != Unknown {
+		t.Errorf("Expected the step timeout to apply to the scoped run, but Result was %v", c.Result)
+	}
+}
+
+func TestEvaluateNotApplicable(t *testing.T) {
+	tests := []struct {
+		testName          string
+		userApplicability []string
+		expectedResult    Result
+	}{
+		{
+			testName:          "All assessments not applicable",
+			userApplicability: []string{"unmatched-applicability"},
+			expectedResult:    NotApplicable,
+		},
+		{
+			testName:          "One assessment not applicable",
+			userApplicability: []string{"other-applicability"},
+			expectedResult:    Passed,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			c := &ControlEvaluation{
+				Assessments: []*Assessment{
+					passingAssessmentPtr(),
+					{
+						RequirementId: "otherAssessment",
+						Description:   "assessment with other applicability",
+						Applicability: []string{"other-applicability"},
+						Steps:         []AssessmentStep{passingAssessmentStep},
+					},
+				},
+			}
+			c.Evaluate(nil, test.userApplicability, false)
+
+			if c.Result != test.expectedResult {
+				t.Errorf("Expected Result to be %v, but it was %v", test.expectedResult, c.Result)
+			}
+			for _, assessment := range c.Assessments {
+				if assessment.Result == NotRun {
+					t.Errorf("Expected %s to be run or marked NotApplicable, but it was NotRun", assessment.RequirementId)
+				}
+				if assessment.Start == "" {
+					t.Errorf("Expected %s to record a start time", assessment.RequirementId)
+				}
+				if assessment.Result == NotApplicable && !strings.Contains(assessment.Message, assessment.Applicability[0]) {
+					t.Errorf("Expected message to name the unmatched applicability, got %q", assessment.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancellingStep := func(interface{}, map[string]*Change) (Result, string) {
+		cancel()
+		return Passed, ""
+	}
+	first := passingAssessmentPtr()
+	first.Steps = []AssessmentStep{cancellingStep}
+	second := passingAssessmentPtr()
+	c := &ControlEvaluation{Assessments: []*Assessment{first, second}}
+
+	c.EvaluateContext(ctx, nil, testingApplicability, true)
+
+	if c.Result != Unknown {
+		t.Errorf("Expected Result to be Unknown, but it was %v", c.Result)
+	}
+	if second.Result != NotRun {
+		t.Errorf("Expected assessments after cancellation not to run, but got %v", second.Result)
+	}
+	if !strings.Contains(c.Message, context.Canceled.Error()) {
+		t.Errorf("Expected message to describe the cancellation, got %q", c.Message)
+	}
+}
+
+// TestEvaluateAbandonedStep ensures that a step which is still running after a timeout cannot apply a change
+// once the changes are reverted, and that the evaluation is reported as corrupted
+func TestEvaluateAbandonedStep(t *testing.T) {
+	returned := make(chan bool)
+	lateStep := func(_ interface{}, changes map[string]*Change) (Result, string) {
+		time.Sleep(50 * time.Millisecond)
+		applied, _ := changes["pendingChange"].Apply("target_name", "target_object", "change_input")
+		returned <- applied
+		return Passed, ""
+	}
+	change := pendingChangePtr()
+	assessment := passingAssessmentPtr()
+	assessment.Steps = []AssessmentStep{lateStep}
+	assessment.Changes = map[string]*Change{"pendingChange": change}
+	c := &ControlEvaluation{Assessments: []*Assessment{assessment}}
+
+	c.EvaluateContext(context.Background(), nil, testingApplicability, true,
+		WithStepTimeout(10*time.Millisecond), WithCleanupCoordinator(NewCleanupCoordinator(WithoutSignalHandling())))
+
+	if applied := <-returned; applied {
+		t.Errorf("Expected the change not to be applied after the changes were reverted")
+	}
+	if change.Applied {
+		t.Errorf("Expected the change not to be recorded as applied")
+	}
+	if !c.CorruptedState {
+		t.Errorf("Expected CorruptedState to be true while the step was still running")
+	}
+}
+
+// TestEvaluateRevertFailure ensures that a change which could not be reverted is described in the Message
+func TestEvaluateRevertFailure(t *testing.T) {
+	applyingStep := func(_ interface{}, changes map[string]*Change) (Result, string) {
+		changes["badRevertChange"].Apply("target_name", "target_object", "change_input")
+		return Passed, "applied"
+	}
+	assessment := passingAssessmentPtr()
+	assessment.Steps = []AssessmentStep{applyingStep}
+	assessment.Changes = map[string]*Change{"badRevertChange": badRevertChangePtr()}
+	c := &ControlEvaluation{Assessments: []*Assessment{assessment}}
+
+	c.EvaluateContext(context.Background(), nil, testingApplicability, true,
+		WithCleanupCoordinator(NewCleanupCoordinator(WithoutSignalHandling())))
+
+	if !c.CorruptedState {
+		t.Errorf("Expected CorruptedState to be true")
+	}
+	if !strings.HasPrefix(c.Message, "applied; changes could not be reverted") || !strings.Contains(c.Message, "error reverting change badRevertChange") {
+		t.Errorf("Expected the message to describe the failed revert, got %q", c.Message)
+	}
+}
+
+// TestEvaluateContinueOnFailure ensures that every step and assessment runs after a failure when requested
+func TestEvaluateContinueOnFailure(t *testing.T) {
+	messageStep := func(interface{}, map[string]*Change) (Result, string) {
+		return Failed, "first failure"
+	}
+	first := failingAssessmentPtr()
+	first.Steps = []AssessmentStep{messageStep, needsReviewAssessmentStep, passingAssessmentStep}
+	second := passingAssessmentPtr()
+	c := &ControlEvaluation{Assessments: []*Assessment{first, second}}
+
+	c.EvaluateContext(context.Background(), nil, testingApplicability, true, WithContinueOnFailure())
+
+	if c.Result != Failed {
+		t.Errorf("Expected Result to be Failed, but it was %v", c.Result)
+	}
+	if first.StepsExecuted != 3 {
+		t.Errorf("Expected every step to run after a failure, but %d ran", first.StepsExecuted)
+	}
+	if second.Result != Passed {
+		t.Errorf("Expected assessments after a failure to run, but got %v", second.Result)
+	}
+	if first.Message != "first failure" || c.Message != "first failure" {
+		t.Errorf("Expected the failure message to be kept, got %q and %q", first.Message, c.Message)
+	}
+}
+
+// TestEvaluateDryRun ensures that a dry run records planned changes without applying them
+func TestEvaluateDryRun(t *testing.T) {
+	var applied bool
+	change := pendingChangePtr()
+	change.applyFunc = func(interface{}) (interface{}, error) {
+		applied = true
+		return nil, nil
+	}
+	applyingStep := func(_ interface{}, changes map[string]*Change) (Result, string) {
+		changes["pendingChange"].Apply("target_name", "target_object", "change_input")
+		return Passed, ""
+	}
+	assessment := passingAssessmentPtr()
+	assessment.Steps = []AssessmentStep{applyingStep}
+	assessment.Changes = map[string]*Change{"pendingChange": change}
+	results := &EvaluationResults{EvaluationSet: []*ControlEvaluation{{Assessments: []*Assessment{assessment}}}}
+
+	results.EvaluateContext(context.Background(), nil, testingApplicability, false, WithDryRun())
+
+	if applied || change.Applied {
+		t.Errorf("Expected the change not to be applied during a dry run")
+	}
+	planned := results.PlannedChanges()
+	if len(planned) != 1 || planned[0] != change {
+		t.Fatalf("Expected the change to be listed as planned, got %v", planned)
+	}
+	if change.TargetName != "target_name" || change.PlannedInput != "change_input" {
+		t.Errorf("Expected the planned target and input to be recorded, got %q and %v", change.TargetName, change.PlannedInput)
+	}
+}
+
+// TestEvaluateBlockedApply ensures that an apply function which has not returned when the step times out does not
+// prevent the changes from being reverted, and that the change is reverted once the apply function returns
+func TestEvaluateBlockedApply(t *testing.T) {
+	unblock := make(chan struct{})
+	returned := make(chan bool)
+	change := pendingChangePtr()
+	change.applyFunc = func(interface{}) (interface{}, error) {
+		<-unblock
+		return nil, nil
+	}
+	blockedStep := func(_ interface{}, changes map[string]*Change) (Result, string) {
+		applied, _ := changes["pendingChange"].Apply("target_name", "target_object", "change_input")
+		returned <- applied
+		return Passed, ""
+	}
+	assessment := passingAssessmentPtr()
+	assessment.Steps = []AssessmentStep{blockedStep}
+	assessment.Changes = map[string]*Change{"pendingChange": change}
+	c := &ControlEvaluation{Assessments: []*Assessment{assessment}}
+
+	done := make(chan struct{})
+	go func() {
+		c.EvaluateContext(context.Background(), nil, testingApplicability, true,
+			WithStepTimeout(10*time.Millisecond), WithCleanupCoordinator(NewCleanupCoordinator(WithoutSignalHandling())))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the evaluation to complete while the apply function was blocked")
+	}
+	if !c.CorruptedState {
+		t.Errorf("Expected CorruptedState to be true while the change was being applied")
+	}
+	if !strings.Contains(c.Message, "change pendingChange was still being applied") {
+		t.Errorf("Expected the message to describe the change that was being applied, got %q", c.Message)
+	}
+
+	close(unblock)
+	<-returned
+	if !change.Applied || !change.Reverted {
+		t.Errorf("Expected the change to be reverted once it was applied, got Applied=%v, Reverted=%v", change.Applied, change.Reverted)
+	}
+}
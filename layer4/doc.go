@@ -0,0 +1,11 @@
+// Package layer4 provides the Layer 4 evaluation types, and runs the steps of each assessment against a target
+// to produce evaluation results.
+//
+// Steps created by ContextStep, Step and NamedStep.Step are plain AssessmentStep functions, so that they can be
+// used anywhere an AssessmentStep is accepted. They are recognized by the code pointer of the closure created by
+// the unexported wrapStep function, which is marked go:noinline so that every wrapped step shares that closure
+// whatever inlining decisions the compiler makes. A wrapped step receives the context and target data in an
+// unexported payload type. AssessmentStep.String and AssessmentStep.Description call a wrapped step with a
+// payload that returns the declared name and description without running the wrapped function, so serializing
+// a step never runs its checks. A wrapped step that is called from another closure is named after that closure.
+package layer4
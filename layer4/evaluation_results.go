@@ -0,0 +1,97 @@
+package layer4
+
+import (
+	"context"
+	"sync"
+)
+
+// EvaluationResults is the top-level Layer 4 document, containing the results of every ControlEvaluation
+// that was run against a single target.
+type EvaluationResults struct {
+	// Result is the aggregate result of all control evaluations in the set
+	Result Result `json:"result" yaml:"result"`
+	// CorruptedState is true if any control evaluation in the set was unable to revert its changes
+	CorruptedState bool `json:"corrupted-state,omitempty" yaml:"corrupted-state,omitempty"`
+	// EvaluationSet is a slice of pointers to the ControlEvaluation objects owned by this document
+	EvaluationSet []*ControlEvaluation `json:"evaluation-set" yaml:"evaluation-set"`
+}
+
+// AddControlEvaluation creates a new ControlEvaluation and adds it to the EvaluationResults.
+func (e *EvaluationResults) AddControlEvaluation(name string, controlId string) *ControlEvaluation {
+	controlEvaluation := &ControlEvaluation{
+		Name:      name,
+		ControlID: controlId,
+	}
+	e.EvaluationSet = append(e.EvaluationSet, controlEvaluation)
+	return controlEvaluation
+}
+
+// Evaluate runs every ControlEvaluation in the set against the targetData, aggregating each result into
+// the EvaluationResults. The userApplicability and changesAllowed values are passed through to
+// ControlEvaluation.Evaluate unchanged.
+func (e *EvaluationResults) Evaluate(targetData interface{}, userApplicability []string, changesAllowed bool) {
+	e.EvaluateContext(context.Background(), targetData, userApplicability, changesAllowed)
+}
+
+// EvaluateContext runs every ControlEvaluation in the same way as Evaluate, passing the context and options to
+// ControlEvaluation.EvaluateContext. Use WithConcurrency to run independent ControlEvaluations at the same time.
+// ControlEvaluations that declare Changes are never run at the same time as any other ControlEvaluation when
+// changesAllowed is true, because they share a single target, but ControlEvaluations without Changes may run at
+// the same time as each other. The targetData must be safe for concurrent reads by the assessment steps.
+// Every ControlEvaluation is tracked by the same CleanupCoordinator, so an interrupt reverts all in-flight changes.
+func (e *EvaluationResults) EvaluateContext(ctx context.Context, targetData interface{}, userApplicability []string, changesAllowed bool, opts ...RunOption) {
+	if len(e.EvaluationSet) == 0 {
+		e.Result = NeedsReview
+		return
+	}
+	workers := newRunOpts(opts).concurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	// Evaluations that may change the target hold the write lock, so that no other evaluation observes the target
+	// while it is being changed
+	var changesMutex sync.RWMutex
+	queue := make(chan *ControlEvaluation)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for controlEvaluation := range queue {
+				if !changesAllowed {
+					controlEvaluation.evaluate(ctx, targetData, userApplicability, changesAllowed, opts)
+					continue
+				}
+				lock, unlock := changesMutex.RLock, changesMutex.RUnlock
+				if controlEvaluation.hasChanges() {
+					lock, unlock = changesMutex.Lock, changesMutex.Unlock
+				}
+				lock()
+				controlEvaluation.evaluate(ctx, targetData, userApplicability, changesAllowed, opts)
+				unlock()
+			}
+		}()
+	}
+	for _, controlEvaluation := range e.EvaluationSet {
+		queue <- controlEvaluation
+	}
+	close(queue)
+	wg.Wait()
+
+	for _, controlEvaluation := range e.EvaluationSet {
+		e.Result = UpdateAggregateResult(e.Result, controlEvaluation.Result)
+		if controlEvaluation.CorruptedState {
+			e.CorruptedState = true
+		}
+	}
+}
+
+// PlannedChanges returns the changes that were recorded by every ControlEvaluation during a dry run.
+func (e *EvaluationResults) PlannedChanges() []*Change {
+	var planned []*Change
+	for _, controlEvaluation := range e.EvaluationSet {
+		planned = append(planned, controlEvaluation.PlannedChanges()...)
+	}
+	return planned
+}
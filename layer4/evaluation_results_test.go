@@ -0,0 +1,218 @@
+package layer4
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+var evaluationResultsTestData = []struct {
+	testName          string
+	results           *EvaluationResults
+	expectedResult    Result
+	expectedCorrupted bool
+}{
+	{
+		testName:       "EvaluationResults with no ControlEvaluations",
+		expectedResult: NeedsReview,
+		results:        &EvaluationResults{},
+	},
+	{
+		testName:       "EvaluationResults with one passing ControlEvaluation",
+		expectedResult: Passed,
+		results: &EvaluationResults{
+			EvaluationSet: []*ControlEvaluation{
+				{Assessments: []*Assessment{passingAssessmentPtr()}},
+			},
+		},
+	},
+	{
+		testName:       "EvaluationResults with passing and NeedsReview ControlEvaluations",
+		expectedResult: NeedsReview,
+		results: &EvaluationResults{
+			EvaluationSet: []*ControlEvaluation{
+				{Assessments: []*Assessment{passingAssessmentPtr()}},
+				{Assessments: []*Assessment{needsReviewAssessmentPtr()}},
+			},
+		},
+	},
+	{
+		testName:       "EvaluationResults with failing and Unknown ControlEvaluations",
+		expectedResult: Failed,
+		results: &EvaluationResults{
+			EvaluationSet: []*ControlEvaluation{
+				{Assessments: []*Assessment{failingAssessmentPtr()}},
+				{Assessments: []*Assessment{unknownAssessmentPtr()}},
+			},
+		},
+	},
+}
+
+// TestEvaluationResultsEvaluate ensures that EvaluationResults.Evaluate runs every ControlEvaluation and aggregates the results
+func TestEvaluationResultsEvaluate(t *testing.T) {
+	for _, test := range evaluationResultsTestData {
+		t.Run(test.testName, func(t *testing.T) {
+			test.results.Evaluate(nil, testingApplicability, true)
+
+			if test.results.Result != test.expectedResult {
+				t.Errorf("Expected Result to be %v, but it was %v", test.expectedResult, test.results.Result)
+			}
+			if test.results.CorruptedState != test.expectedCorrupted {
+				t.Errorf("Expected CorruptedState to be %v, but it was %v", test.expectedCorrupted, test.results.CorruptedState)
+			}
+			for _, controlEvaluation := range test.results.EvaluationSet {
+				if controlEvaluation.Result == NotRun {
+					t.Errorf("Expected every ControlEvaluation to be run, but found one with result %v", controlEvaluation.Result)
+				}
+			}
+		})
+	}
+}
+
+// TestEvaluationResultsConcurrency ensures that running ControlEvaluations concurrently produces the same aggregate results
+func TestEvaluationResultsConcurrency(t *testing.T) {
+	for _, test := range evaluationResultsTestData {
+		t.Run(test.testName, func(t *testing.T) {
+			results := &EvaluationResults{}
+			for _, controlEvaluation := range test.results.EvaluationSet {
+				assessments := make([]*Assessment, len(controlEvaluation.Assessments))
+				for i, assessment := range controlEvaluation.Assessments {
+					a := *assessment
+					a.Result = NotRun
+					assessments[i] = &a
+				}
+				results.EvaluationSet = append(results.EvaluationSet, &ControlEvaluation{Assessments: assessments})
+			}
+			results.EvaluateContext(context.Background(), nil, testingApplicability, true, WithConcurrency(3))
+
+			if results.Result != test.expectedResult {
+				t.Errorf("Expected Result to be %v, but it was %v", test.expectedResult, results.Result)
+			}
+		})
+	}
+}
+
+// TestEvaluationResultsConcurrentChanges ensures that ControlEvaluations with Changes are not run at the same time
+// as any other ControlEvaluation, while ControlEvaluations without Changes may run at the same time as each other
+func TestEvaluationResultsConcurrentChanges(t *testing.T) {
+	var writers, readers, maxWriters, maxReaders int32
+	var overlapped atomic.Bool
+	recordMax := func(current int32, highest *int32) {
+		for {
+			previous := atomic.LoadInt32(highest)
+			if current <= previous || atomic.CompareAndSwapInt32(highest, previous, current) {
+				return
+			}
+		}
+	}
+	writeStep := func(interface{}, map[string]*Change) (Result, string) {
+		recordMax(atomic.AddInt32(&writers, 1), &maxWriters)
+		if atomic.LoadInt32(&readers) > 0 {
+			overlapped.Store(true)
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&writers, -1)
+		return Passed, ""
+	}
+	readStep := func(interface{}, map[string]*Change) (Result, string) {
+		recordMax(atomic.AddInt32(&readers, 1), &maxReaders)
+		if atomic.LoadInt32(&writers) > 0 {
+			overlapped.Store(true)
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&readers, -1)
+		return Passed, ""
+	}
+
+	results := &EvaluationResults{}
+	for i := 0; i < 8; i++ {
+		assessment := &Assessment{
+			RequirementId: "concurrent-changes",
+			Description:   "concurrent changes",
+			Applicability: testingApplicability,
+			Steps:         []AssessmentStep{readStep},
+		}
+		// Alternate pairs of read-only evaluations with evaluations that declare Changes
+		if i%3 == 2 {
+			assessment.Steps = []AssessmentStep{writeStep}
+			assessment.Changes = map[string]*Change{"pendingChange": pendingChangePtr()}
+		}
+		results.EvaluationSet = append(results.EvaluationSet, &ControlEvaluation{Assessments: []*Assessment{assessment}})
+	}
+	results.EvaluateContext(context.Background(), nil, testingApplicability, true, WithConcurrency(4))
+
+	if maxWriters != 1 {
+		t.Errorf("Expected ControlEvaluations with Changes to run one at a time, but %d ran at once", maxWriters)
+	}
+	if overlapped.Load() {
+		t.Errorf("Expected no read-only ControlEvaluation to run at the same time as one with Changes")
+	}
+	if maxReaders < 2 {
+		t.Errorf("Expected read-only ControlEvaluations to run at the same time, but at most %d ran at once", maxReaders)
+	}
+	if results.Result != Passed {
+		t.Errorf("Expected Result to be %v, but it was %v", Passed, results.Result)
+	}
+}
+
+func TestAddControlEvaluation(t *testing.T) {
+	results := &EvaluationResults{}
+	controlEvaluation := results.AddControlEvaluation("test-name", "test-control")
+
+	if len(results.EvaluationSet) != 1 {
+		t.Fatalf("Expected 1 ControlEvaluation, got %d", len(results.EvaluationSet))
+	}
+	if results.EvaluationSet[0] != controlEvaluation {
+		t.Errorf("Found different ControlEvaluation in EvaluationResults than the one returned by AddControlEvaluation")
+	}
+	if controlEvaluation.Name != "test-name" || controlEvaluation.ControlID != "test-control" {
+		t.Errorf("Expected name and control ID to be set, got name=%q, control-id=%q", controlEvaluation.Name, controlEvaluation.ControlID)
+	}
+}
+
+// TestEvaluationResultsSerialization ensures that EvaluationResults uses the field names from the layer 4 schema
+func TestEvaluationResultsSerialization(t *testing.T) {
+	results := &EvaluationResults{}
+	controlEvaluation := results.AddControlEvaluation("test-name", "test-control")
+	controlEvaluation.Assessments = []*Assessment{passingAssessmentPtr()}
+	results.Evaluate(nil, testingApplicability, false)
+
+	jsonData, err := json.Marshal(results)
+	if err != nil {
+		t.Fatalf("Unexpected error marshaling JSON: %v", err)
+	}
+	yamlData, err := yaml.Marshal(results)
+	if err != nil {
+		t.Fatalf("Unexpected error marshaling YAML: %v", err)
+	}
+
+	for name, data := range map[string][]byte{"json": jsonData, "yaml": yamlData} {
+		var decoded map[string]interface{}
+		if err := yaml.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Unexpected error decoding %s output: %v", name, err)
+		}
+		if decoded["result"] != "Passed" {
+			t.Errorf("Expected %s result to be 'Passed', got %v", name, decoded["result"])
+		}
+		set, ok := decoded["evaluation-set"].([]interface{})
+		if !ok || len(set) != 1 {
+			t.Fatalf("Expected %s output to contain an evaluation-set with 1 entry, got %v", name, decoded["evaluation-set"])
+		}
+		evaluation := set[0].(map[string]interface{})
+		for _, key := range []string{"name", "control-id", "result", "message", "corrupted-state", "assessments"} {
+			if _, found := evaluation[key]; !found {
+				t.Errorf("Expected %s control evaluation to contain key %q", name, key)
+			}
+		}
+		assessment := evaluation["assessments"].([]interface{})[0].(map[string]interface{})
+		for _, key := range []string{"requirement-id", "applicability", "description", "result", "message", "steps", "start"} {
+			if _, found := assessment[key]; !found {
+				t.Errorf("Expected %s assessment to contain key %q", name, key)
+			}
+		}
+	}
+}
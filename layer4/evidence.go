@@ -0,0 +1,130 @@
+package layer4
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Base64Encoding is the Encoding of Evidence whose Content is base64-encoded, because it is not valid UTF-8.
+const Base64Encoding = "base64"
+
+// Evidence is an item collected during an assessment to support its result.
+// The evidence is either stored inline as Content, or referenced as a file or URI in Reference.
+type Evidence struct {
+	// Name is a human-readable name for the evidence
+	Name string `json:"name" yaml:"name"`
+	// MediaType is the IANA media type of the evidence, such as "application/json"
+	MediaType string `json:"media-type" yaml:"media-type"`
+	// Content is the evidence itself, if it is stored inline
+	Content string `json:"content,omitempty" yaml:"content,omitempty"`
+	// Encoding is Base64Encoding if the Content is base64-encoded, or empty if the Content is the evidence as text
+	Encoding string `json:"encoding,omitempty" yaml:"encoding,omitempty"`
+	// Reference is the location of the evidence, if it is stored outside of the results
+	Reference string `json:"reference,omitempty" yaml:"reference,omitempty"`
+	// SHA256 is the hex-encoded SHA-256 digest of the evidence content
+	SHA256 string `json:"sha256" yaml:"sha256"`
+	// Collected is the time the evidence was collected
+	Collected string `json:"collected" yaml:"collected"`
+	// Step is the name of the step that collected the evidence
+	Step string `json:"step,omitempty" yaml:"step,omitempty"`
+}
+
+// NewEvidence creates an Evidence item with the content stored inline. Content that is not valid UTF-8, such as
+// an image, is base64-encoded so that it is not altered when the results are serialized.
+func NewEvidence(name, mediaType string, content []byte) Evidence {
+	digest := sha256.Sum256(content)
+	evidence := Evidence{
+		Name:      name,
+		MediaType: mediaType,
+		Content:   string(content),
+		SHA256:    hex.EncodeToString(digest[:]),
+		Collected: time.Now().Format(time.RFC3339),
+	}
+	if !utf8.Valid(content) {
+		evidence.Content = base64.StdEncoding.EncodeToString(content)
+		evidence.Encoding = Base64Encoding
+	}
+	return evidence
+}
+
+// Bytes returns the inline content of the evidence, decoding it according to the Encoding. The SHA256 digest is
+// computed from these bytes.
+func (e Evidence) Bytes() ([]byte, error) {
+	switch e.Encoding {
+	case "":
+		return []byte(e.Content), nil
+	case Base64Encoding:
+		content, err := base64.StdEncoding.DecodeString(e.Content)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding content of evidence %s: %w", e.Name, err)
+		}
+		return content, nil
+	default:
+		return nil, fmt.Errorf("evidence %s has an unsupported encoding: %s", e.Name, e.Encoding)
+	}
+}
+
+// NewFileEvidence creates an Evidence item that references a file, recording the digest of its current content.
+func NewFileEvidence(name, mediaType, filePath string) (Evidence, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return Evidence{}, fmt.Errorf("error reading evidence file: %w", err)
+	}
+	evidence := NewEvidence(name, mediaType, content)
+	evidence.Content = ""
+	evidence.Encoding = ""
+	evidence.Reference = filePath
+	return evidence, nil
+}
+
+// AddEvidence attaches evidence to the assessment.
+func (a *Assessment) AddEvidence(evidence ...Evidence) {
+	a.Evidence = append(a.Evidence, evidence...)
+}
+
+// AttachEvidence attaches evidence to the assessment whose ContextAssessmentStep received the context.
+// The evidence Step is set to the name of the running step. It returns an error if the context
+// was not provided by a running step, or if the step has already been interrupted.
+func AttachEvidence(ctx context.Context, evidence Evidence) error {
+	collector, ok := ctx.Value(evidenceCollectorKey{}).(*evidenceCollector)
+	if !ok {
+		return fmt.Errorf("evidence %s can only be attached from a running step", evidence.Name)
+	}
+	return collector.add(evidence)
+}
+
+type evidenceCollectorKey struct{}
+
+// evidenceCollector gathers evidence attached by a single step, which may be running on its own goroutine.
+type evidenceCollector struct {
+	mutex    sync.Mutex
+	step     string
+	evidence []Evidence
+	closed   bool
+}
+
+func (c *evidenceCollector) add(evidence Evidence) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		return fmt.Errorf("evidence %s was attached after step %s finished", evidence.Name, c.step)
+	}
+	evidence.Step = c.step
+	c.evidence = append(c.evidence, evidence)
+	return nil
+}
+
+// close prevents further evidence from being attached and returns the evidence collected so far.
+func (c *evidenceCollector) close() []Evidence {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.closed = true
+	return c.evidence
+}
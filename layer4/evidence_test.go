@@ -0,0 +1,114 @@
+package layer4
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNewEvidence(t *testing.T) {
+	evidence := NewEvidence("config", "text/plain", []byte("hello"))
+	if evidence.SHA256 != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("Unexpected digest %s", evidence.SHA256)
+	}
+	if evidence.Content != "hello" || evidence.Encoding != "" || evidence.Collected == "" {
+		t.Errorf("Expected content and collection time to be set, got %+v", evidence)
+	}
+}
+
+// TestEvidenceBinaryContent ensures that binary content is serialized without changing its digest
+func TestEvidenceBinaryContent(t *testing.T) {
+	content := []byte{0x89, 'P', 'N', 'G', 0xff, 0}
+	evidence := NewEvidence("image", "image/png", content)
+	if evidence.Encoding != Base64Encoding {
+		t.Errorf("Expected binary content to be base64-encoded, got encoding %q", evidence.Encoding)
+	}
+
+	data, err := json.Marshal(evidence)
+	if err != nil {
+		t.Fatalf("Unexpected error marshalling evidence: %v", err)
+	}
+	var loaded Evidence
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("Unexpected error unmarshalling evidence: %v", err)
+	}
+	decoded, err := loaded.Bytes()
+	if err != nil {
+		t.Fatalf("Unexpected error decoding evidence: %v", err)
+	}
+	digest := sha256.Sum256(decoded)
+	if hex.EncodeToString(digest[:]) != evidence.SHA256 {
+		t.Errorf("Expected the digest of the decoded content to match %s, got %x", evidence.SHA256, digest)
+	}
+
+	loaded.Encoding = "gzip"
+	if _, err := loaded.Bytes(); err == nil {
+		t.Errorf("Expected an error for an unsupported encoding")
+	}
+}
+
+func TestNewFileEvidence(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "evidence.txt")
+	if err := os.WriteFile(filePath, []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	evidence, err := NewFileEvidence("config", "text/plain", filePath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if evidence.Reference != filePath || evidence.Content != "" {
+		t.Errorf("Expected a file reference without inline content, got %+v", evidence)
+	}
+	if evidence.SHA256 != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("Unexpected digest %s", evidence.SHA256)
+	}
+
+	if _, err := NewFileEvidence("missing", "text/plain", filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Errorf("Expected an error for a missing file")
+	}
+}
+
+// TestAttachEvidence ensures that steps can attach evidence which is serialized with the assessment
+func TestAttachEvidence(t *testing.T) {
+	step := ContextStep(func(ctx context.Context, payload interface{}, c map[string]*Change) (Result, string) {
+		if err := AttachEvidence(ctx, NewEvidence("payload", "text/plain", []byte("data"))); err != nil {
+			return Unknown, err.Error()
+		}
+		return Passed, ""
+	})
+	assessment := Assessment{
+		RequirementId: "evidence",
+		Description:   "evidence",
+		Applicability: testingApplicability,
+		Steps:         []AssessmentStep{step},
+	}
+	assessment.Run(nil, false)
+
+	if assessment.Result != Passed {
+		t.Fatalf("Expected the step to pass, got %s: %s", assessment.Result, assessment.Message)
+	}
+	if len(assessment.Evidence) != 1 || assessment.Evidence[0].Step != step.String() {
+		t.Fatalf("Expected one evidence item from step %s, got %+v", step.String(), assessment.Evidence)
+	}
+
+	data, err := json.Marshal(assessment)
+	if err != nil {
+		t.Fatalf("Unexpected error marshalling assessment: %v", err)
+	}
+	var loaded Assessment
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("Unexpected error unmarshalling assessment: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Evidence, assessment.Evidence) {
+		t.Errorf("Expected evidence to round-trip, got %+v", loaded.Evidence)
+	}
+
+	if err := AttachEvidence(context.Background(), NewEvidence("orphan", "text/plain", nil)); err == nil {
+		t.Errorf("Expected an error when attaching evidence outside of a step")
+	}
+}
@@ -0,0 +1,212 @@
+package layer4
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Journal events recorded for a Change
+const (
+	// JournalApply is recorded before a change is applied
+	JournalApply = "apply"
+	// JournalRevert is recorded after a change is successfully reverted
+	JournalRevert = "revert"
+	// JournalFailed is recorded after the apply function of a change returns an error
+	JournalFailed = "failed"
+)
+
+// JournalEntry is a single record in a Journal.
+type JournalEntry struct {
+	// Id identifies a single application of a change, and is shared by its apply and revert entries
+	Id string `json:"id" yaml:"id"`
+	// Event is one of JournalApply, JournalRevert or JournalFailed
+	Event string `json:"event" yaml:"event"`
+	// RequirementId is the ID of the requirement of the Assessment that made the change
+	RequirementId string `json:"requirement-id,omitempty" yaml:"requirement-id,omitempty"`
+	// ChangeName is the name of the change in Assessment.Changes
+	ChangeName string `json:"change-name" yaml:"change-name"`
+	// TargetName is the name or ID of the resource that is changed
+	TargetName string `json:"target-name" yaml:"target-name"`
+	// Description is a human-readable description of the change
+	Description string `json:"description" yaml:"description"`
+	// TargetObject is supplemental data describing the object that is changed
+	TargetObject interface{} `json:"target-object,omitempty" yaml:"target-object,omitempty"`
+	// Time is the time the entry was recorded
+	Time string `json:"time" yaml:"time"`
+}
+
+// Journal is an append-only file of JSON lines which records each Change before it is applied and after it is
+// reverted, so that changes left behind by a crashed process can be reverted by Recover.
+// A Journal may be shared by concurrent evaluations.
+type Journal struct {
+	mutex sync.Mutex
+	path  string
+	count int
+}
+
+// OpenJournal opens the journal at the path, creating the file if it does not exist.
+// A partial line left by a process that stopped while writing is terminated so that new entries are readable.
+func OpenJournal(path string) (*Journal, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening journal: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("error opening journal: %w", err)
+	}
+	if info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+			return nil, fmt.Errorf("error opening journal: %w", err)
+		}
+		if last[0] != '\n' {
+			if _, err := file.Write([]byte{'\n'}); err != nil {
+				return nil, fmt.Errorf("error opening journal: %w", err)
+			}
+		}
+	}
+	return &Journal{path: path}, nil
+}
+
+// Pending returns the apply entries that have not been reverted, in the order they were recorded.
+func (j *Journal) Pending() ([]JournalEntry, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.pending()
+}
+
+// Recover reverts every pending entry in the reverse order of application, using the revert function registered
+// under "<RequirementId>/<ChangeName>", or under the ChangeName if there is none for the requirement. Entries
+// without a registered revert function, or whose revert fails, remain pending and are reported in the returned error.
+//
+// Unlike the reversion at the end of an evaluation, which calls the revert function with nil, each revert function
+// receives the TargetObject recorded when the change was applied. It has been decoded from JSON, so a struct is
+// received as a map[string]interface{} and a number as a float64.
+func (j *Journal) Recover(reverts map[string]RevertFunc) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	pending, err := j.pending()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for i := len(pending) - 1; i >= 0; i-- {
+		entry := pending[i]
+		revert, ok := reverts[entry.RequirementId+"/"+entry.ChangeName]
+		if !ok {
+			revert, ok = reverts[entry.ChangeName]
+		}
+		if !ok {
+			errs = append(errs, fmt.Errorf("no revert function registered for change %s (%s)", entry.ChangeName, entry.Id))
+			continue
+		}
+		if err := revert(entry.TargetObject); err != nil {
+			errs = append(errs, fmt.Errorf("error reverting change %s (%s): %w", entry.ChangeName, entry.Id, err))
+			continue
+		}
+		entry.Event = JournalRevert
+		if err := j.write(entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// recordApply writes an apply entry for the change and returns the id of the entry.
+func (j *Journal) recordApply(name string, change *Change, targetName string, targetObject interface{}) (string, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.count++
+	id := strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.Itoa(j.count)
+	return id, j.write(JournalEntry{
+		Id:            id,
+		Event:         JournalApply,
+		RequirementId: change.requirementId,
+		ChangeName:    name,
+		TargetName:    targetName,
+		Description:   change.Description,
+		TargetObject:  targetObject,
+	})
+}
+
+// record writes an entry that closes the apply entry with the id.
+func (j *Journal) record(id string, event string, name string, change *Change) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.write(JournalEntry{
+		Id:            id,
+		Event:         event,
+		RequirementId: change.requirementId,
+		ChangeName:    name,
+		TargetName:    change.TargetName,
+		Description:   change.Description,
+	})
+}
+
+// write appends the entry to the journal and syncs it to disk. The caller must hold the mutex.
+func (j *Journal) write(entry JournalEntry) error {
+	entry.Time = time.Now().Format(time.RFC3339)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error writing journal: %w", err)
+	}
+	file, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("error writing journal: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing journal: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("error writing journal: %w", err)
+	}
+	return nil
+}
+
+// pending reads the journal and returns the apply entries without a matching revert or failed entry.
+// The caller must hold the mutex.
+func (j *Journal) pending() ([]JournalEntry, error) {
+	file, err := os.Open(j.path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading journal: %w", err)
+	}
+	defer file.Close()
+
+	var applied []JournalEntry
+	closed := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// A partial line is left if the process stopped while writing it, which is always before the
+			// change was applied or after it was reverted, so the line is skipped
+			continue
+		}
+		if entry.Event == JournalApply {
+			applied = append(applied, entry)
+		} else {
+			closed[entry.Id] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading journal: %w", err)
+	}
+
+	var pending []JournalEntry
+	for _, entry := range applied {
+		if !closed[entry.Id] {
+			pending = append(pending, entry)
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,159 @@
+package layer4
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func journaledChange(t *testing.T, journal *Journal) *Change {
+	t.Helper()
+	change := pendingChangePtr()
+	change.journal = journal
+	change.name = "pendingChange"
+	change.Allow()
+	if applied, _ := change.Apply("target_name", "target_object", "change_input"); !applied {
+		t.Fatalf("Expected the change to be applied, got error %v", change.Error)
+	}
+	return change
+}
+
+// TestJournalEvaluate ensures that changes applied and reverted during an evaluation leave no pending entries
+func TestJournalEvaluate(t *testing.T) {
+	journal, err := OpenJournal(filepath.Join(t.TempDir(), "journal.jsonl"))
+	if err != nil {
+		t.Fatalf("Unexpected error opening journal: %v", err)
+	}
+	applyingStep := func(_ interface{}, changes map[string]*Change) (Result, string) {
+		changes["pendingChange"].Apply("target_name", "target_object", "change_input")
+		return Passed, ""
+	}
+	assessment := passingAssessmentPtr()
+	assessment.Steps = []AssessmentStep{applyingStep}
+	c := &ControlEvaluation{Assessments: []*Assessment{assessment}}
+	c.EvaluateContext(context.Background(), nil, testingApplicability, true, WithJournal(journal))
+
+	if !assessment.Changes["pendingChange"].Reverted {
+		t.Fatalf("Expected the change to be reverted")
+	}
+	pending, err := journal.Pending()
+	if err != nil {
+		t.Fatalf("Unexpected error reading journal: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("Expected no pending entries, got %v", pending)
+	}
+}
+
+// TestJournalRecover ensures that changes which were never reverted are reverted by Recover
+func TestJournalRecover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	journal, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("Unexpected error opening journal: %v", err)
+	}
+	journaledChange(t, journal)
+
+	// Reopen the journal as a new process would after a crash
+	journal, err = OpenJournal(path)
+	if err != nil {
+		t.Fatalf("Unexpected error opening journal: %v", err)
+	}
+	pending, err := journal.Pending()
+	if err != nil {
+		t.Fatalf("Unexpected error reading journal: %v", err)
+	}
+	if len(pending) != 1 || pending[0].ChangeName != "pendingChange" || pending[0].TargetName != "target_name" {
+		t.Fatalf("Expected one pending entry for pendingChange, got %v", pending)
+	}
+
+	if err := journal.Recover(map[string]RevertFunc{}); err == nil {
+		t.Errorf("Expected an error when no revert function is registered")
+	}
+
+	var reverted interface{}
+	err = journal.Recover(map[string]RevertFunc{
+		"pendingChange": func(data interface{}) error {
+			reverted = data
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error recovering journal: %v", err)
+	}
+	if reverted != "target_object" {
+		t.Errorf("Expected the revert function to receive the target object, got %v", reverted)
+	}
+	pending, err = journal.Pending()
+	if err != nil {
+		t.Fatalf("Unexpected error reading journal: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("Expected no pending entries after recovery, got %v", pending)
+	}
+}
+
+// TestJournalPartialLine ensures that a partial line left by a crash does not prevent the journal from being read
+func TestJournalPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	if err := os.WriteFile(path, []byte(`{"id":"partial","event":"ap`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	journal, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("Unexpected error opening journal: %v", err)
+	}
+	journaledChange(t, journal)
+
+	pending, err := journal.Pending()
+	if err != nil {
+		t.Fatalf("Unexpected error reading journal: %v", err)
+	}
+	if len(pending) != 1 {
+		t.Errorf("Expected one pending entry, got %v", pending)
+	}
+}
+
+// TestJournalRecoverContract ensures that changes with the same name in different assessments are recovered by
+// their qualified names, and that each revert function receives the recorded TargetObject as decoded JSON
+func TestJournalRecoverContract(t *testing.T) {
+	type target struct {
+		Branch string `json:"branch"`
+	}
+	journal, err := OpenJournal(filepath.Join(t.TempDir(), "journal.jsonl"))
+	if err != nil {
+		t.Fatalf("Unexpected error opening journal: %v", err)
+	}
+	for _, requirementId := range []string{"OSPS-AC-01.01", "OSPS-AC-03.01"} {
+		change := pendingChangePtr()
+		change.journal = journal
+		change.name = "pendingChange"
+		change.requirementId = requirementId
+		change.Allow()
+		change.Apply("target_name", target{Branch: requirementId}, nil)
+	}
+
+	received := make(map[string]interface{})
+	revertFor := func(key string) RevertFunc {
+		return func(data interface{}) error {
+			received[key] = data
+			return nil
+		}
+	}
+	err = journal.Recover(map[string]RevertFunc{
+		"OSPS-AC-01.01/pendingChange": revertFor("OSPS-AC-01.01"),
+		"pendingChange":               revertFor("pendingChange"),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error recovering journal: %v", err)
+	}
+	expected := map[string]interface{}{
+		"OSPS-AC-01.01": map[string]interface{}{"branch": "OSPS-AC-01.01"},
+		"pendingChange": map[string]interface{}{"branch": "OSPS-AC-03.01"},
+	}
+	if !reflect.DeepEqual(received, expected) {
+		t.Errorf("Expected the revert functions to receive %v, got %v", expected, received)
+	}
+}
@@ -0,0 +1,31 @@
+package layer4
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/ossf/gemara/internal/loaders"
+)
+
+// LoadFile loads previous evaluation results from a YAML or JSON file at the provided path.
+// sourcePath is expected to be a file or https URI in the form file:///path/to/file.yaml or https://example.com/file.yaml.
+// Loaded assessment steps and changes cannot be executed again, because their functions are not serialized.
+// If run multiple times for the same data type, this method will override previous data.
+func (e *EvaluationResults) LoadFile(sourcePath string) error {
+	ext := path.Ext(sourcePath)
+	switch ext {
+	case ".yaml", ".yml":
+		err := loaders.LoadYAML(sourcePath, e)
+		if err != nil {
+			return err
+		}
+	case ".json":
+		err := loaders.LoadJSON(sourcePath, e)
+		if err != nil {
+			return fmt.Errorf("error loading json: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported file extension: %s", ext)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package layer4
+
+// This file contains table tests for the following functions:
+// - EvaluationResults.LoadFile
+
+// The test data is pulled from ./test-data/
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+var loaderTests = []struct {
+	name       string
+	sourcePath string
+	wantErr    bool
+}{
+	{
+		name:       "Bad path",
+		sourcePath: "file://bad-path.yaml",
+		wantErr:    true,
+	},
+	{
+		name:       "Bad YAML",
+		sourcePath: "file://test-data/bad.yaml",
+		wantErr:    true,
+	},
+	{
+		name:       "Good YAML — Privateer baseline scan",
+		sourcePath: "file://test-data/pvtr-baseline-scan.yaml",
+		wantErr:    false,
+	},
+	{
+		name:       "Good JSON — evaluation results",
+		sourcePath: "file://test-data/evaluation-results.json",
+		wantErr:    false,
+	},
+	{
+		name:       "Unrecognized file extension",
+		sourcePath: "file://test-data/unknown.ext",
+		wantErr:    true,
+	},
+}
+
+func Test_LoadFile(t *testing.T) {
+	for _, tt := range loaderTests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &EvaluationResults{}
+			err := e.LoadFile(tt.sourcePath)
+			if (err == nil) == tt.wantErr {
+				t.Errorf("EvaluationResults.LoadFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(e.EvaluationSet) == 0 {
+				t.Errorf("EvaluationResults.LoadFile() did not load any control evaluations")
+			} else if !tt.wantErr {
+				assert.NotEmpty(t, e.EvaluationSet[0].ControlID, "Control ID should not be empty")
+				assert.NotEqual(t, NotRun, e.EvaluationSet[0].Result, "Result should be loaded from the file")
+				assert.NotEmpty(t, e.EvaluationSet[0].Assessments, "Assessments should not be empty")
+			}
+		})
+	}
+}
+
+// Test_LoadFile_RoundTrip ensures that loaded results are written back out unchanged
+func Test_LoadFile_RoundTrip(t *testing.T) {
+	e := &EvaluationResults{}
+	if err := e.LoadFile("file://test-data/evaluation-results.json"); err != nil {
+		t.Fatalf("Unexpected error loading file: %v", err)
+	}
+
+	assessment := e.EvaluationSet[0].Assessments[0]
+	assert.Equal(t, Failed, assessment.Result)
+	assert.Len(t, assessment.Steps, 1)
+	assert.EqualError(t, assessment.Changes["deleteBranch"].Error, "failed to revert: branch not found")
+
+	expectedStep := "github.com/revanite-io/pvtr-github-repo/evaluation_plans/osps/access_control.branchProtectionPreventsDeletion"
+	jsonData, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("Unexpected error marshaling JSON: %v", err)
+	}
+	yamlData, err := yaml.Marshal(e)
+	if err != nil {
+		t.Fatalf("Unexpected error marshaling YAML: %v", err)
+	}
+
+	fromJSON := &EvaluationResults{}
+	if err := json.Unmarshal(jsonData, fromJSON); err != nil {
+		t.Fatalf("Unexpected error unmarshaling JSON: %v", err)
+	}
+	fromYAML := &EvaluationResults{}
+	if err := yaml.Unmarshal(yamlData, fromYAML); err != nil {
+		t.Fatalf("Unexpected error unmarshaling YAML: %v", err)
+	}
+	for name, loaded := range map[string]*EvaluationResults{"json": fromJSON, "yaml": fromYAML} {
+		reloaded := loaded.EvaluationSet[0].Assessments[0]
+		assert.Equal(t, Failed, loaded.Result, name)
+		assert.Equal(t, []string{expectedStep}, reloaded.stepNameList(), name)
+		assert.Equal(t, assessment.Start, reloaded.Start, name)
+		assert.EqualError(t, reloaded.Changes["deleteBranch"].Error, "failed to revert: branch not found", name)
+	}
+}
+
+// TestLoadedStep ensures that a loaded step cannot be mistaken for a passing result
+func TestLoadedStep(t *testing.T) {
+	var step AssessmentStep
+	if err := json.Unmarshal([]byte(`"github.com/example/pkg.someStep"`), &step); err != nil {
+		t.Fatalf("Unexpected error unmarshaling step: %v", err)
+	}
+	result, _ := step(nil, nil)
+	assert.Equal(t, NeedsReview, result)
+}
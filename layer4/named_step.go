@@ -0,0 +1,22 @@
+package layer4
+
+import "context"
+
+// NamedStep declares an assessment step with a stable name and description, so that serialized results do not
+// depend on the name of the function in the source code.
+type NamedStep struct {
+	// Name is the name used when the step is serialized or reported
+	Name string
+	// Description is a human-readable description of what the step checks
+	Description string
+	// Func is the step to run, which may itself be created by ContextStep or Step
+	Func AssessmentStep
+}
+
+// Step returns an AssessmentStep that runs the Func and reports the declared Name and Description,
+// so that the NamedStep can be used anywhere an AssessmentStep is accepted.
+func (n NamedStep) Step() AssessmentStep {
+	return wrapStep(n.Name, n.Description, func(ctx context.Context, payload interface{}, c map[string]*Change) (Result, string) {
+		return callStep(ctx, n.Func, payload, c)
+	})
+}
@@ -0,0 +1,57 @@
+package layer4
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestNamedStep(t *testing.T) {
+	var receivedDeadline bool
+	step := NamedStep{
+		Name:        "branch-protection-enabled",
+		Description: "checks that the default branch is protected",
+		Func: ContextStep(func(ctx context.Context, payload interface{}, c map[string]*Change) (Result, string) {
+			_, receivedDeadline = ctx.Deadline()
+			return Passed, ""
+		}),
+	}.Step()
+
+	if step.String() != "branch-protection-enabled" {
+		t.Errorf("Expected the declared name, got %s", step.String())
+	}
+	if step.Description() != "checks that the default branch is protected" {
+		t.Errorf("Expected the declared description, got %s", step.Description())
+	}
+	if AssessmentStep(passingAssessmentStep).Description() != "" {
+		t.Errorf("Expected a bare function to have no description, got %s", AssessmentStep(passingAssessmentStep).Description())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if result, _ := callStep(ctx, step, nil, nil); result != Passed || !receivedDeadline {
+		t.Errorf("Expected the wrapped step to run with the context, got %s", result)
+	}
+
+	assessment := Assessment{Steps: []AssessmentStep{step, passingAssessmentStep}}
+	jsonData, err := json.Marshal(assessment)
+	if err != nil {
+		t.Fatalf("Unexpected error marshalling JSON: %v", err)
+	}
+	yamlData, err := yaml.Marshal(assessment)
+	if err != nil {
+		t.Fatalf("Unexpected error marshalling YAML: %v", err)
+	}
+	for _, data := range []string{string(jsonData), string(yamlData)} {
+		if !strings.Contains(data, "branch-protection-enabled") {
+			t.Errorf("Expected the declared name to be serialized, got %s", data)
+		}
+		if !strings.Contains(data, "github.com/ossf/gemara/layer4.init.func") {
+			t.Errorf("Expected bare functions to fall back to the function name, got %s", data)
+		}
+	}
+}
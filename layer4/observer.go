@@ -0,0 +1,31 @@
+package layer4
+
+// Observer receives events as ControlEvaluations and Assessments are run. It may be used for progress reporting,
+// structured logging, or metrics. An Observer must always be safe for concurrent use: change events are delivered
+// from the goroutines that run steps, and from the goroutine that reverts changes after an interrupt, at the same
+// time as other events. Change events are delivered while the changes of the Assessment are locked, so an Observer
+// must not call back into the evaluation, such as by applying or reverting a Change, and should return quickly.
+type Observer interface {
+	// OnEvaluationStart is called before the first assessment of a ControlEvaluation is run
+	OnEvaluationStart(evaluation *ControlEvaluation)
+	// OnAssessmentStart is called before the first step of an Assessment is run
+	OnAssessmentStart(assessment *Assessment)
+	// OnStepResult is called after each step returns, or is interrupted, with the result and message of the step
+	OnStepResult(assessment *Assessment, step AssessmentStep, result Result, message string)
+	// OnChangeApplied is called after a Change is successfully applied
+	OnChangeApplied(change *Change)
+	// OnChangeReverted is called after a Change is successfully reverted
+	OnChangeReverted(change *Change)
+	// OnEvaluationEnd is called after a ControlEvaluation is complete and its changes have been reverted
+	OnEvaluationEnd(evaluation *ControlEvaluation)
+}
+
+// NoopObserver is an Observer that ignores every event. It may be embedded to implement only some of the methods.
+type NoopObserver struct{}
+
+func (NoopObserver) OnEvaluationStart(*ControlEvaluation)                     {}
+func (NoopObserver) OnAssessmentStart(*Assessment)                            {}
+func (NoopObserver) OnStepResult(*Assessment, AssessmentStep, Result, string) {}
+func (NoopObserver) OnChangeApplied(*Change)                                  {}
+func (NoopObserver) OnChangeReverted(*Change)                                 {}
+func (NoopObserver) OnEvaluationEnd(*ControlEvaluation)                       {}
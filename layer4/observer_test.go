@@ -0,0 +1,88 @@
+package layer4
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type recordingObserver struct {
+	events   []string
+	messages []string
+}
+
+func (o *recordingObserver) OnEvaluationStart(*ControlEvaluation) {
+	o.events = append(o.events, "evaluation-start")
+}
+func (o *recordingObserver) OnAssessmentStart(*Assessment) {
+	o.events = append(o.events, "assessment-start")
+}
+func (o *recordingObserver) OnStepResult(_ *Assessment, _ AssessmentStep, result Result, message string) {
+	o.events = append(o.events, "step-"+result.String())
+	o.messages = append(o.messages, result.String()+":"+message)
+}
+func (o *recordingObserver) OnChangeApplied(*Change) {
+	o.events = append(o.events, "change-applied")
+}
+func (o *recordingObserver) OnChangeReverted(*Change) {
+	o.events = append(o.events, "change-reverted")
+}
+func (o *recordingObserver) OnEvaluationEnd(*ControlEvaluation) {
+	o.events = append(o.events, "evaluation-end")
+}
+
+// TestObserverEvents ensures that an Observer receives every event in the order it occurs
+func TestObserverEvents(t *testing.T) {
+	applyingStep := func(_ interface{}, changes map[string]*Change) (Result, string) {
+		changes["pendingChange"].Apply("target_name", "target_object", "change_input")
+		return Passed, ""
+	}
+	evaluation := &ControlEvaluation{
+		Assessments: []*Assessment{{
+			RequirementId: "observer",
+			Description:   "observer assessment",
+			Applicability: testingApplicability,
+			Steps:         []AssessmentStep{applyingStep, needsReviewAssessmentStep},
+			Changes:       map[string]*Change{"pendingChange": pendingChangePtr()},
+		}},
+	}
+	observer := &recordingObserver{}
+	evaluation.EvaluateContext(context.Background(), nil, testingApplicability, true,
+		WithObserver(observer), WithCleanupCoordinator(NewCleanupCoordinator(WithoutSignalHandling())))
+
+	expected := []string{
+		"evaluation-start",
+		"assessment-start",
+		"change-applied",
+		"step-Passed",
+		"step-Needs Review",
+		"change-reverted",
+		"evaluation-end",
+	}
+	if !reflect.DeepEqual(observer.events, expected) {
+		t.Errorf("Expected events %v, got %v", expected, observer.events)
+	}
+}
+
+// TestObserverStepMessages ensures that each step result is reported with the message of that step,
+// even when the Assessment keeps the message of an earlier failure
+func TestObserverStepMessages(t *testing.T) {
+	failingStep := func(interface{}, map[string]*Change) (Result, string) {
+		return Failed, "first failed"
+	}
+	passingStep := func(interface{}, map[string]*Change) (Result, string) {
+		return Passed, "second passed"
+	}
+	assessment := failingAssessmentPtr()
+	assessment.Steps = []AssessmentStep{failingStep, passingStep}
+	observer := &recordingObserver{}
+	assessment.RunContext(context.Background(), nil, false, WithObserver(observer), WithContinueOnFailure())
+
+	expected := []string{"Failed:first failed", "Passed:second passed"}
+	if !reflect.DeepEqual(observer.messages, expected) {
+		t.Errorf("Expected step messages %v, got %v", expected, observer.messages)
+	}
+	if assessment.Message != "first failed" {
+		t.Errorf("Expected the assessment to keep the failure message, got %q", assessment.Message)
+	}
+}
@@ -0,0 +1,200 @@
+package layer4
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/defenseunicorns/go-oscal/src/pkg/uuid"
+	oscal "github.com/defenseunicorns/go-oscal/src/types/oscal-1-1-3"
+
+	oscalUtils "github.com/ossf/gemara/internal/oscal"
+	"github.com/ossf/gemara/layer2"
+)
+
+// ToOSCAL converts the EvaluationResults to an OSCAL Assessment Results document.
+// Parameters:
+//   - catalog: the Layer 2 Catalog that defines the evaluated controls and assessment requirements
+//   - assessmentPlanHref: location of the OSCAL Assessment Plan that the results were produced for
+//
+// The function automatically:
+//   - Creates an OSCAL result for each ControlEvaluation, reviewing the evaluated control
+//   - Creates an observation for each Assessment, with the exact result recorded as a property
+//   - Creates a finding for each Assessment that was run, targeting the assessment requirement as an objective,
+//     which matches the part IDs produced by layer2 Catalog.ToOSCAL. Assessments that are NotApplicable or NotRun
+//     have no finding, so that they are not counted as unsatisfied objectives
+//   - Maps Passed to a satisfied finding, Failed to not-satisfied, and Unknown or NeedsReview to not-satisfied
+//     with the reason "other"
+//
+// An error is returned if a control or assessment requirement is not found in the catalog.
+func (e *EvaluationResults) ToOSCAL(catalog *layer2.Catalog, assessmentPlanHref string) (oscal.AssessmentResults, error) {
+	now := time.Now()
+
+	requirements := make(map[string]map[string]layer2.AssessmentRequirement)
+	for _, family := range catalog.ControlFamilies {
+		for _, control := range family.Controls {
+			requirements[control.Id] = make(map[string]layer2.AssessmentRequirement)
+			for _, requirement := range control.AssessmentRequirements {
+				requirements[control.Id][requirement.Id] = requirement
+			}
+		}
+	}
+
+	results := make([]oscal.Result, 0, len(e.EvaluationSet))
+	for _, controlEvaluation := range e.EvaluationSet {
+		controlRequirements, ok := requirements[controlEvaluation.ControlID]
+		if !ok {
+			return oscal.AssessmentResults{}, fmt.Errorf("control %s not found in catalog %s", controlEvaluation.ControlID, catalog.Metadata.Id)
+		}
+		result, err := controlEvaluation.toOSCALResult(controlRequirements, now)
+		if err != nil {
+			return oscal.AssessmentResults{}, err
+		}
+		results = append(results, result)
+	}
+
+	return oscal.AssessmentResults{
+		UUID: uuid.NewUUID(),
+		ImportAp: oscal.ImportAp{
+			Href: assessmentPlanHref,
+		},
+		Metadata: oscal.Metadata{
+			LastModified: now,
+			OscalVersion: oscalUtils.OSCALVersion,
+			Published:    &now,
+			Title:        fmt.Sprintf("Evaluation results for %s", catalog.Metadata.Title),
+			Version:      catalog.Metadata.Version,
+		},
+		Results: results,
+	}, nil
+}
+
+// toOSCALResult creates an OSCAL result for the ControlEvaluation, with an observation for each Assessment and a
+// finding for each Assessment that was run.
+func (c *ControlEvaluation) toOSCALResult(requirements map[string]layer2.AssessmentRequirement, now time.Time) (oscal.Result, error) {
+	var findings []oscal.Finding
+	var observations []oscal.Observation
+	var start, end *time.Time
+	for _, assessment := range c.Assessments {
+		requirement, ok := requirements[assessment.RequirementId]
+		if !ok {
+			return oscal.Result{}, fmt.Errorf("assessment requirement %s not found in control %s", assessment.RequirementId, c.ControlID)
+		}
+		observation := assessment.toOSCALObservation(now)
+		observations = append(observations, observation)
+		if assessment.Result != NotApplicable && assessment.Result != NotRun {
+			findings = append(findings, assessment.toOSCALFinding(requirement, observation.UUID))
+		}
+
+		if t := oscalUtils.GetTime(assessment.Start); t != nil && (start == nil || t.Before(*start)) {
+			start = t
+		}
+		if t := oscalUtils.GetTime(assessment.End); t != nil && (end == nil || t.After(*end)) {
+			end = t
+		}
+	}
+	if start == nil {
+		start = &now
+	}
+
+	result := oscal.Result{
+		UUID:        uuid.NewUUID(),
+		Title:       c.Name,
+		Description: fmt.Sprintf("Evaluation of control %s", c.ControlID),
+		Remarks:     c.Message,
+		Start:       *start,
+		End:         end,
+		Props: &[]oscal.Property{
+			resultProperty(c.Result),
+		},
+		ReviewedControls: oscal.ReviewedControls{
+			ControlSelections: []oscal.AssessedControls{
+				{
+					IncludeControls: &[]oscal.AssessedControlsSelectControlById{
+						{ControlId: c.ControlID},
+					},
+				},
+			},
+		},
+		Findings:     oscalUtils.NilIfEmpty(findings),
+		Observations: oscalUtils.NilIfEmpty(observations),
+	}
+	if result.Title == "" {
+		result.Title = c.ControlID
+	}
+	return result, nil
+}
+
+// toOSCALObservation creates an OSCAL observation describing how the Assessment was run and the evidence it collected.
+func (a *Assessment) toOSCALObservation(now time.Time) oscal.Observation {
+	var evidence []oscal.RelevantEvidence
+	for _, item := range a.Evidence {
+		evidence = append(evidence, oscal.RelevantEvidence{
+			Description: item.Name,
+			Href:        item.Reference,
+			Props: &[]oscal.Property{
+				{Name: "media-type", Ns: oscalUtils.GemaraNamespace, Value: item.MediaType},
+				{Name: "sha256", Ns: oscalUtils.GemaraNamespace, Value: item.SHA256},
+			},
+		})
+	}
+
+	description := fmt.Sprintf("Ran %d of %d steps for %s", a.StepsExecuted, len(a.Steps), a.RequirementId)
+	return oscal.Observation{
+		UUID:        uuid.NewUUID(),
+		Title:       a.RequirementId,
+		Description: description,
+		Remarks:     a.Message,
+		Methods:     []string{"TEST"},
+		Props: &[]oscal.Property{
+			resultProperty(a.Result),
+		},
+		Collected:        oscalUtils.GetTimeWithFallback(a.End, oscalUtils.GetTimeWithFallback(a.Start, now)),
+		RelevantEvidence: oscalUtils.NilIfEmpty(evidence),
+	}
+}
+
+// toOSCALFinding creates an OSCAL finding for the Assessment, targeting the assessment requirement as an objective.
+func (a *Assessment) toOSCALFinding(requirement layer2.AssessmentRequirement, observationUUID string) oscal.Finding {
+	description := requirement.Text
+	if description == "" {
+		description = a.RequirementId
+	}
+	return oscal.Finding{
+		UUID:        uuid.NewUUID(),
+		Title:       a.RequirementId,
+		Description: description,
+		Remarks:     a.Message,
+		Props: &[]oscal.Property{
+			resultProperty(a.Result),
+		},
+		Target: oscal.FindingTarget{
+			Type:     "objective-id",
+			TargetId: requirement.Id,
+			Status:   objectiveStatus(a.Result),
+		},
+		RelatedObservations: &[]oscal.RelatedObservation{
+			{ObservationUuid: observationUUID},
+		},
+	}
+}
+
+// objectiveStatus maps the Result of an Assessment that was run to the status of an OSCAL finding target.
+func objectiveStatus(result Result) oscal.ObjectiveStatus {
+	switch result {
+	case Passed:
+		return oscal.ObjectiveStatus{State: "satisfied", Reason: "pass"}
+	case Failed:
+		return oscal.ObjectiveStatus{State: "not-satisfied", Reason: "fail"}
+	default:
+		return oscal.ObjectiveStatus{State: "not-satisfied", Reason: "other", Remarks: result.String()}
+	}
+}
+
+// resultProperty records the Layer 4 result as an OSCAL property in the Gemara namespace.
+func resultProperty(result Result) oscal.Property {
+	return oscal.Property{
+		Name:  "result",
+		Ns:    oscalUtils.GemaraNamespace,
+		Value: result.String(),
+	}
+}
@@ -0,0 +1,88 @@
+package layer4
+
+import (
+	"testing"
+
+	oscal "github.com/defenseunicorns/go-oscal/src/types/oscal-1-1-3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	oscalUtils "github.com/ossf/gemara/internal/oscal"
+)
+
+func Test_ToOSCAL(t *testing.T) {
+	catalog := testCatalog()
+	catalog.Metadata.Version = "devel"
+
+	controlEvaluation, err := NewControlEvaluation(catalog, "AC-01")
+	require.NoError(t, err)
+	controlEvaluation.Assessments[0].AddStep(passingAssessmentStep)
+	controlEvaluation.Assessments[0].AddEvidence(NewEvidence("mfa-settings", "application/json", []byte(`{"mfa":true}`)))
+	controlEvaluation.Assessments[1].AddStep(failingAssessmentStep)
+	controlEvaluation.Assessments[1].Applicability = testingApplicability
+	results := &EvaluationResults{EvaluationSet: []*ControlEvaluation{controlEvaluation}}
+	results.Evaluate(nil, testingApplicability, false)
+
+	assessmentResults, err := results.ToOSCAL(catalog, "https://example.com/assessment-plan.json")
+	require.NoError(t, err)
+	assert.NoError(t, oscalUtils.Validate(oscal.OscalModels{AssessmentResults: &assessmentResults}))
+
+	require.Len(t, assessmentResults.Results, 1)
+	result := assessmentResults.Results[0]
+	assert.Equal(t, "AC-01", (*result.ReviewedControls.ControlSelections[0].IncludeControls)[0].ControlId)
+	require.NotNil(t, result.Findings)
+	require.NotNil(t, result.Observations)
+	findings := *result.Findings
+	require.Len(t, findings, 2)
+	assert.Equal(t, "AC-01.01", findings[0].Target.TargetId)
+	assert.Equal(t, "satisfied", findings[0].Target.Status.State)
+	assert.Equal(t, "AC-01.02", findings[1].Target.TargetId)
+	assert.Equal(t, "not-satisfied", findings[1].Target.Status.State)
+	assert.Equal(t, (*result.Observations)[0].UUID, (*findings[0].RelatedObservations)[0].ObservationUuid)
+	assert.Len(t, *(*result.Observations)[0].RelevantEvidence, 1)
+}
+
+func Test_ToOSCALNotApplicable(t *testing.T) {
+	catalog := testCatalog()
+	catalog.Metadata.Version = "devel"
+	controlEvaluation, err := NewControlEvaluation(catalog, "AC-01")
+	require.NoError(t, err)
+	controlEvaluation.Assessments[0].AddStep(passingAssessmentStep)
+	controlEvaluation.Assessments[1].AddStep(failingAssessmentStep)
+	results := &EvaluationResults{EvaluationSet: []*ControlEvaluation{controlEvaluation}}
+	results.Evaluate(nil, testingApplicability, false)
+	require.Equal(t, NotApplicable, controlEvaluation.Assessments[1].Result)
+
+	assessmentResults, err := results.ToOSCAL(catalog, "https://example.com/assessment-plan.json")
+	require.NoError(t, err)
+	assert.NoError(t, oscalUtils.Validate(oscal.OscalModels{AssessmentResults: &assessmentResults}))
+
+	result := assessmentResults.Results[0]
+	require.NotNil(t, result.Findings)
+	findings := *result.Findings
+	require.Len(t, findings, 1)
+	assert.Equal(t, "AC-01.01", findings[0].Target.TargetId)
+	observations := *result.Observations
+	require.Len(t, observations, 2)
+	assert.Equal(t, "AC-01.02", observations[1].Title)
+	assert.Equal(t, NotApplicable.String(), (*observations[1].Props)[0].Value)
+}
+
+func Test_ToOSCALUnknownControl(t *testing.T) {
+	results := &EvaluationResults{EvaluationSet: []*ControlEvaluation{{ControlID: "XX-01"}}}
+	_, err := results.ToOSCAL(testCatalog(), "https://example.com/assessment-plan.json")
+	assert.Error(t, err)
+
+	results = &EvaluationResults{EvaluationSet: []*ControlEvaluation{{
+		ControlID:   "AC-01",
+		Assessments: []*Assessment{{RequirementId: "AC-01.99"}},
+	}}}
+	_, err = results.ToOSCAL(testCatalog(), "https://example.com/assessment-plan.json")
+	assert.Error(t, err)
+}
+
+func TestObjectiveStatus(t *testing.T) {
+	assert.Equal(t, "satisfied", objectiveStatus(Passed).State)
+	assert.Equal(t, "fail", objectiveStatus(Failed).Reason)
+	assert.Equal(t, oscal.ObjectiveStatus{State: "not-satisfied", Reason: "other", Remarks: "Needs Review"}, objectiveStatus(NeedsReview))
+}
@@ -0,0 +1,59 @@
+package layer4
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestPanicIsolation ensures that panics in steps and change functions are recovered, and that applied changes
+// are still reverted
+func TestPanicIsolation(t *testing.T) {
+	change := pendingChangePtr()
+	panickingStep := func(_ interface{}, changes map[string]*Change) (Result, string) {
+		changes["pendingChange"].Apply("target_name", "target_object", "change_input")
+		panic("step exploded")
+	}
+	assessment := passingAssessmentPtr()
+	assessment.Steps = []AssessmentStep{panickingStep}
+	assessment.Changes = map[string]*Change{"pendingChange": change}
+	c := &ControlEvaluation{Assessments: []*Assessment{assessment}}
+
+	c.EvaluateContext(context.Background(), nil, testingApplicability, true)
+
+	if assessment.Result != Unknown {
+		t.Errorf("Expected a panicking step to be Unknown, got %s", assessment.Result)
+	}
+	if !strings.Contains(assessment.Message, "step exploded") || !strings.Contains(assessment.Message, "goroutine") {
+		t.Errorf("Expected the panic and stack trace in the message, got %q", assessment.Message)
+	}
+	if !change.Reverted {
+		t.Errorf("Expected the change to be reverted after a panic")
+	}
+}
+
+func TestChangePanics(t *testing.T) {
+	applyPanic := pendingChangePtr()
+	applyPanic.applyFunc = func(interface{}) (interface{}, error) {
+		panic("apply exploded")
+	}
+	applyPanic.Allow()
+	if applied, _ := applyPanic.Apply("target_name", "target_object", "change_input"); applied {
+		t.Errorf("Expected a panicking apply function not to apply the change")
+	}
+	if applyPanic.Error == nil || !strings.Contains(applyPanic.Error.Error(), "apply exploded") {
+		t.Errorf("Expected the panic to be recorded as the change error, got %v", applyPanic.Error)
+	}
+
+	revertPanic := goodNotRevertedChangePtr()
+	revertPanic.revertFunc = func(interface{}) error {
+		panic("revert exploded")
+	}
+	revertPanic.Revert(nil)
+	if revertPanic.Reverted {
+		t.Errorf("Expected a panicking revert function not to revert the change")
+	}
+	if revertPanic.Error == nil || !strings.Contains(revertPanic.Error.Error(), "revert exploded") {
+		t.Errorf("Expected the panic to be recorded as the change error, got %v", revertPanic.Error)
+	}
+}
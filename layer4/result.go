@@ -1,6 +1,9 @@
 package layer4
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 // Result is an enum representing the result of a control evaluation
 // This is designed to restrict the possible result values to a set of known states
@@ -38,7 +41,38 @@ func (r Result) MarshalJSON() ([]byte, error) {
 	return json.Marshal(r.String())
 }
 
+// UnmarshalYAML parses a Result from its string representation in YAML
+func (r *Result) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	return r.parse(value)
+}
+
+// UnmarshalJSON parses a Result from its string representation in JSON
+func (r *Result) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	return r.parse(value)
+}
+
+// parse sets the Result to the value matching the provided string, returning an error if no match is found.
+func (r *Result) parse(value string) error {
+	for result, name := range toString {
+		if name == value {
+			*r = result
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown result value: %q", value)
+}
+
 // UpdateAggregateResult compares the current result with the new result and returns the most severe of the two.
+// NotApplicable is only returned if no other result has been recorded, so that a set of results that are all
+// NotApplicable is reported distinctly from a set that Passed.
 func UpdateAggregateResult(previous Result, new Result) Result {
 	if new == NotRun {
 		// Not Run should not overwrite anything
@@ -47,6 +81,19 @@ func UpdateAggregateResult(previous Result, new Result) Result {
 		return previous
 	}
 
+	if new == NotApplicable {
+		// Not Applicable should only overwrite Not Run
+		if previous == NotRun {
+			return NotApplicable
+		}
+		return previous
+	}
+
+	if previous == NotApplicable {
+		// Any result other than Not Run should overwrite Not Applicable
+		return new
+	}
+
 	if previous == Failed || new == Failed {
 		// Failed should not be overwritten by anything
 		// Failed should overwrite anything
@@ -1,7 +1,10 @@
 package layer4
 
 import (
+	"encoding/json"
 	"testing"
+
+	"github.com/goccy/go-yaml"
 )
 
 func TestResultString(t *testing.T) {
@@ -95,6 +98,36 @@ func TestUpdateAggregateResult(t *testing.T) {
 			new:      NeedsReview,
 			expected: NeedsReview,
 		},
+		{
+			name:     "NotApplicable should overwrite NotRun",
+			prev:     NotRun,
+			new:      NotApplicable,
+			expected: NotApplicable,
+		},
+		{
+			name:     "NotApplicable should not overwrite Passed",
+			prev:     Passed,
+			new:      NotApplicable,
+			expected: Passed,
+		},
+		{
+			name:     "NotApplicable should not overwrite Failed",
+			prev:     Failed,
+			new:      NotApplicable,
+			expected: Failed,
+		},
+		{
+			name:     "Passed should overwrite NotApplicable",
+			prev:     NotApplicable,
+			new:      Passed,
+			expected: Passed,
+		},
+		{
+			name:     "NotRun should not overwrite NotApplicable",
+			prev:     NotApplicable,
+			new:      NotRun,
+			expected: NotApplicable,
+		},
 	}
 
 	for _, test := range tests {
@@ -106,3 +139,43 @@ func TestUpdateAggregateResult(t *testing.T) {
 		})
 	}
 }
+
+func TestResultUnmarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Result
+		wantErr  bool
+	}{
+		{name: "Not Run", input: "Not Run", expected: NotRun},
+		{name: "Passed", input: "Passed", expected: Passed},
+		{name: "Failed", input: "Failed", expected: Failed},
+		{name: "Needs Review", input: "Needs Review", expected: NeedsReview},
+		{name: "Not Applicable", input: "Not Applicable", expected: NotApplicable},
+		{name: "Unknown", input: "Unknown", expected: Unknown},
+		{name: "Unrecognized value", input: "Sort Of Passed", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name+"-json", func(t *testing.T) {
+			var actual Result
+			err := json.Unmarshal([]byte(`"`+test.input+`"`), &actual)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Expected error to be %t, got %v", test.wantErr, err)
+			}
+			if !test.wantErr && actual != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, actual)
+			}
+		})
+		t.Run(test.name+"-yaml", func(t *testing.T) {
+			var actual Result
+			err := yaml.Unmarshal([]byte(`"`+test.input+`"`), &actual)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Expected error to be %t, got %v", test.wantErr, err)
+			}
+			if !test.wantErr && actual != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, actual)
+			}
+		})
+	}
+}
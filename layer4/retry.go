@@ -0,0 +1,74 @@
+package layer4
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// RetryPolicy determines when a step is run again after returning a transient result. An attempt that exceeds the
+// limit set by WithStepTimeout is recorded as Unknown and left running in the background. It is only retried if it
+// has returned by the end of the backoff, so that two attempts of a step never run at the same time.
+// Attempts are not retried once the assessment timeout or the context is done.
+type RetryPolicy struct {
+	// Attempts is the maximum number of times a step is run, including the first attempt
+	Attempts int
+	// Backoff returns how long to wait after the numbered attempt before running the step again.
+	// If it is nil, the step is run again immediately.
+	Backoff func(attempt int) time.Duration
+	// Retriable is the list of results that cause the step to be run again. If it is empty, only Unknown is retried.
+	Retriable []Result
+}
+
+// ConstantBackoff returns a backoff function that always waits for the same duration.
+func ConstantBackoff(delay time.Duration) func(attempt int) time.Duration {
+	return func(int) time.Duration {
+		return delay
+	}
+}
+
+// ExponentialBackoff returns a backoff function that waits for the base duration after the first attempt,
+// doubling the wait after each further attempt, up to the longest possible time.Duration.
+func ExponentialBackoff(base time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		shift := attempt - 1
+		if shift < 0 {
+			shift = 0
+		}
+		if shift >= 63 || base > math.MaxInt64>>shift {
+			return math.MaxInt64
+		}
+		return base << shift
+	}
+}
+
+// retry returns true if a step should be run again after the numbered attempt returned the result.
+func (p RetryPolicy) retry(attempt int, result Result) bool {
+	if attempt >= p.Attempts {
+		return false
+	}
+	if len(p.Retriable) == 0 {
+		return result == Unknown
+	}
+	for _, retriable := range p.Retriable {
+		if result == retriable {
+			return true
+		}
+	}
+	return false
+}
+
+// wait blocks for the backoff after the numbered attempt, returning the context error if it is done first.
+func (p RetryPolicy) wait(ctx context.Context, attempt int) error {
+	if p.Backoff == nil {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(p.Backoff(attempt))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,161 @@
+package layer4
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	tests := []struct {
+		testName        string
+		policy          RetryPolicy
+		results         []Result
+		expectedResult  Result
+		expectedAttempt int
+	}{
+		{
+			testName:        "Unknown is retried by default",
+			policy:          RetryPolicy{Attempts: 3},
+			results:         []Result{Unknown, Passed},
+			expectedResult:  Passed,
+			expectedAttempt: 2,
+		},
+		{
+			testName:        "Attempts are limited",
+			policy:          RetryPolicy{Attempts: 2, Backoff: ConstantBackoff(time.Millisecond)},
+			results:         []Result{Unknown, Unknown, Passed},
+			expectedResult:  Unknown,
+			expectedAttempt: 2,
+		},
+		{
+			testName:        "Only retriable results are retried",
+			policy:          RetryPolicy{Attempts: 3, Retriable: []Result{NeedsReview}},
+			results:         []Result{Unknown, Passed},
+			expectedResult:  Unknown,
+			expectedAttempt: 1,
+		},
+		{
+			testName:        "Custom retriable results",
+			policy:          RetryPolicy{Attempts: 3, Retriable: []Result{NeedsReview}, Backoff: ExponentialBackoff(time.Millisecond)},
+			results:         []Result{NeedsReview, NeedsReview, Failed},
+			expectedResult:  Failed,
+			expectedAttempt: 3,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			calls := 0
+			step := func(interface{}, map[string]*Change) (Result, string) {
+				result := test.results[calls]
+				calls++
+				return result, result.String()
+			}
+			assessment := Assessment{
+				RequirementId: "retry",
+				Description:   "retry",
+				Applicability: testingApplicability,
+				Steps:         []AssessmentStep{step},
+			}
+			assessment.RunContext(context.Background(), nil, false, WithRetry(test.policy))
+
+			if assessment.Result != test.expectedResult {
+				t.Errorf("Expected result %s, got %s", test.expectedResult, assessment.Result)
+			}
+			if len(assessment.StepResults) != test.expectedAttempt {
+				t.Fatalf("Expected %d recorded attempts, got %d", test.expectedAttempt, len(assessment.StepResults))
+			}
+			for i, record := range assessment.StepResults {
+				if record.Attempt != i+1 || record.Result != test.results[i] {
+					t.Errorf("Expected attempt %d with result %s, got attempt %d with result %s", i+1, test.results[i], record.Attempt, record.Result)
+				}
+			}
+			if assessment.StepsExecuted != 1 {
+				t.Errorf("Expected one step to be executed, got %d", assessment.StepsExecuted)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyCancelledDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	step := func(interface{}, map[string]*Change) (Result, string) {
+		cancel()
+		return Unknown, "transient"
+	}
+	assessment := Assessment{
+		RequirementId: "retry",
+		Description:   "retry",
+		Applicability: testingApplicability,
+		Steps:         []AssessmentStep{step},
+	}
+	assessment.RunContext(ctx, nil, false, WithRetry(RetryPolicy{Attempts: 3, Backoff: ConstantBackoff(time.Minute)}))
+
+	if assessment.Result != Unknown || len(assessment.StepResults) != 1 {
+		t.Errorf("Expected a single Unknown attempt, got %s with %d attempts", assessment.Result, len(assessment.StepResults))
+	}
+}
+
+// TestRetryPolicyStepTimeout ensures that an attempt which exceeds the step timeout is retried once it has returned
+func TestRetryPolicyStepTimeout(t *testing.T) {
+	var calls atomic.Int32
+	step := func(interface{}, map[string]*Change) (Result, string) {
+		if calls.Add(1) == 1 {
+			time.Sleep(100 * time.Millisecond)
+		}
+		return Passed, ""
+	}
+	assessment := Assessment{
+		RequirementId: "retry",
+		Description:   "retry",
+		Applicability: testingApplicability,
+		Steps:         []AssessmentStep{step},
+	}
+	assessment.RunContext(context.Background(), nil, false,
+		WithStepTimeout(10*time.Millisecond), WithRetry(RetryPolicy{Attempts: 2, Backoff: ConstantBackoff(200 * time.Millisecond)}))
+
+	if assessment.Result != Passed {
+		t.Errorf("Expected the retried attempt to pass, got %s: %s", assessment.Result, assessment.Message)
+	}
+	if len(assessment.StepResults) != 2 || assessment.StepResults[0].Result != Unknown {
+		t.Errorf("Expected a timed out attempt followed by a passing attempt, got %+v", assessment.StepResults)
+	}
+}
+
+// TestRetryPolicyRunningAttempt ensures that a step is not run again while an attempt that timed out is still running
+func TestRetryPolicyRunningAttempt(t *testing.T) {
+	var calls atomic.Int32
+	step := func(interface{}, map[string]*Change) (Result, string) {
+		calls.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		return Passed, ""
+	}
+	assessment := Assessment{
+		RequirementId: "retry",
+		Description:   "retry",
+		Applicability: testingApplicability,
+		Steps:         []AssessmentStep{step},
+	}
+	assessment.RunContext(context.Background(), nil, false,
+		WithStepTimeout(10*time.Millisecond), WithRetry(RetryPolicy{Attempts: 3}))
+
+	if calls.Load() != 1 || len(assessment.StepResults) != 1 {
+		t.Errorf("Expected a single attempt while the first attempt was running, got %d calls and %d results", calls.Load(), len(assessment.StepResults))
+	}
+	if assessment.Result != Unknown || !strings.Contains(assessment.Message, "was not retried") {
+		t.Errorf("Expected an Unknown result explaining that the step was not retried, got %s: %s", assessment.Result, assessment.Message)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(time.Second)
+	if backoff(1) != time.Second || backoff(3) != 4*time.Second {
+		t.Errorf("Unexpected backoff durations %s and %s", backoff(1), backoff(3))
+	}
+	if backoff(40) != math.MaxInt64 || backoff(1000) != math.MaxInt64 {
+		t.Errorf("Expected the backoff to be capped, got %s and %s", backoff(40), backoff(1000))
+	}
+}
@@ -0,0 +1,108 @@
+package layer4
+
+import "time"
+
+type runOpts struct {
+	stepTimeout       time.Duration
+	assessmentTimeout time.Duration
+	concurrency       int
+	coordinator       *CleanupCoordinator
+	observer          Observer
+	continueOnFailure bool
+	dryRun            bool
+	journal           *Journal
+	retry             RetryPolicy
+}
+
+// RunOption defines an option to tune the behavior of Assessment.RunContext and ControlEvaluation.EvaluateContext.
+type RunOption func(opts *runOpts)
+
+// WithStepTimeout is a RunOption that limits how long each step may run. A step that does not return in time
+// is recorded as Unknown, and the remaining steps in the assessment are not run. The step is left running in the
+// background, but it cannot apply a Change once the changes of the assessment are reverted.
+func WithStepTimeout(timeout time.Duration) RunOption {
+	return func(opts *runOpts) {
+		opts.stepTimeout = timeout
+	}
+}
+
+// WithAssessmentTimeout is a RunOption that limits how long all steps in each assessment may run in total.
+// If the limit is reached, the running step is recorded as Unknown and the remaining steps are not run.
+func WithAssessmentTimeout(timeout time.Duration) RunOption {
+	return func(opts *runOpts) {
+		opts.assessmentTimeout = timeout
+	}
+}
+
+// WithConcurrency is a RunOption that sets how many ControlEvaluations EvaluationResults.EvaluateContext may run
+// at the same time. Values below one run the ControlEvaluations one at a time.
+func WithConcurrency(workers int) RunOption {
+	return func(opts *runOpts) {
+		opts.concurrency = workers
+	}
+}
+
+// WithContinueOnFailure is a RunOption that runs every step and every applicable assessment, even after a
+// Failed result, so that all findings are reported. The aggregate result is still Failed.
+func WithContinueOnFailure() RunOption {
+	return func(opts *runOpts) {
+		opts.continueOnFailure = true
+	}
+}
+
+// WithDryRun is a RunOption that records each Change a step attempts to apply as Planned, without applying it.
+// The planned changes are included in the results and may be listed with PlannedChanges.
+func WithDryRun() RunOption {
+	return func(opts *runOpts) {
+		opts.dryRun = true
+	}
+}
+
+// WithJournal is a RunOption that records every Change in the Journal before it is applied and after it is
+// reverted, so that Journal.Recover can revert changes left behind if the process does not exit cleanly.
+// The TargetObject of each change must be serializable as JSON, because it is passed to the revert function
+// during recovery.
+func WithJournal(journal *Journal) RunOption {
+	return func(opts *runOpts) {
+		opts.journal = journal
+	}
+}
+
+// WithRetry is a RunOption that runs each step again when it returns a retriable result, as determined by the
+// RetryPolicy. Every attempt is recorded in Assessment.StepResults, and only the final attempt is aggregated.
+// An attempt that exceeds the limit set by WithStepTimeout is retried if Unknown is a retriable result.
+func WithRetry(policy RetryPolicy) RunOption {
+	return func(opts *runOpts) {
+		opts.retry = policy
+	}
+}
+
+// WithCleanupCoordinator is a RunOption that sets the CleanupCoordinator used to track evaluations with pending
+// Changes. DefaultCoordinator is used when this option is not provided.
+func WithCleanupCoordinator(coordinator *CleanupCoordinator) RunOption {
+	return func(opts *runOpts) {
+		opts.coordinator = coordinator
+	}
+}
+
+// WithObserver is a RunOption that sets the Observer notified of evaluation progress.
+// A NoopObserver is used when this option is not provided.
+func WithObserver(observer Observer) RunOption {
+	return func(opts *runOpts) {
+		opts.observer = observer
+	}
+}
+
+func newRunOpts(opts []RunOption) runOpts {
+	options := runOpts{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.coordinator == nil {
+		options.coordinator = DefaultCoordinator()
+	}
+	if options.observer == nil {
+		options.observer = NoopObserver{}
+	}
+	return options
+}
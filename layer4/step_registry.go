@@ -0,0 +1,82 @@
+package layer4
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ossf/gemara/layer2"
+)
+
+// StepRegistry binds AssessmentSteps to the IDs of Layer 2 assessment requirements, such as "OSPS-AC-01.01".
+type StepRegistry struct {
+	steps map[string][]AssessmentStep
+}
+
+// NewStepRegistry creates an empty StepRegistry and returns a pointer to it.
+func NewStepRegistry() *StepRegistry {
+	return &StepRegistry{
+		steps: make(map[string][]AssessmentStep),
+	}
+}
+
+// Register queues the provided steps for the assessment requirement with the provided ID.
+// Steps registered across multiple calls are run in the order they were registered.
+func (r *StepRegistry) Register(requirementId string, steps ...AssessmentStep) {
+	if r.steps == nil {
+		r.steps = make(map[string][]AssessmentStep)
+	}
+	r.steps[requirementId] = append(r.steps[requirementId], steps...)
+}
+
+// Steps returns the steps registered for the assessment requirement with the provided ID.
+func (r *StepRegistry) Steps(requirementId string) []AssessmentStep {
+	return r.steps[requirementId]
+}
+
+// NewEvaluationResults creates a ControlEvaluation for every control in the Layer 2 Catalog, using the steps
+// in the StepRegistry for each assessment requirement. Assessments for requirements without registered steps
+// are marked as NeedsReview so that the gap is visible in the results. An error is returned if the registry
+// has steps for IDs that match no assessment requirement in the catalog, since those steps would never run.
+func NewEvaluationResults(catalog *layer2.Catalog, registry *StepRegistry) (*EvaluationResults, error) {
+	if catalog == nil {
+		return nil, fmt.Errorf("catalog must not be nil")
+	}
+	if registry == nil {
+		return nil, fmt.Errorf("step registry must not be nil")
+	}
+	results := &EvaluationResults{}
+	matched := make(map[string]bool)
+	for _, family := range catalog.ControlFamilies {
+		for _, control := range family.Controls {
+			controlEvaluation := newControlEvaluation(control)
+			for _, assessment := range controlEvaluation.Assessments {
+				steps := registry.Steps(assessment.RequirementId)
+				if len(steps) == 0 {
+					assessment.Result = NeedsReview
+					assessment.Message = fmt.Sprintf("no steps registered for requirement %s", assessment.RequirementId)
+					continue
+				}
+				matched[assessment.RequirementId] = true
+				assessment.Steps = append(assessment.Steps, steps...)
+			}
+			results.EvaluationSet = append(results.EvaluationSet, controlEvaluation)
+		}
+	}
+	if unmatched := registry.unmatched(matched); len(unmatched) > 0 {
+		return nil, fmt.Errorf("steps are registered for requirements that are not in the catalog: %s", strings.Join(unmatched, ", "))
+	}
+	return results, nil
+}
+
+// unmatched returns the sorted IDs with registered steps that are not in the matched set.
+func (r *StepRegistry) unmatched(matched map[string]bool) []string {
+	var ids []string
+	for requirementId, steps := range r.steps {
+		if len(steps) > 0 && !matched[requirementId] {
+			ids = append(ids, requirementId)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
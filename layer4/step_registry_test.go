@@ -0,0 +1,101 @@
+package layer4
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStepRegistryRegister(t *testing.T) {
+	registry := NewStepRegistry()
+	registry.Register("AC-01.01", passingAssessmentStep)
+	registry.Register("AC-01.01", needsReviewAssessmentStep, passingAssessmentStep)
+
+	if len(registry.Steps("AC-01.01")) != 3 {
+		t.Errorf("Expected 3 registered steps, got %d", len(registry.Steps("AC-01.01")))
+	}
+	if len(registry.Steps("AC-01.02")) != 0 {
+		t.Errorf("Expected 0 registered steps, got %d", len(registry.Steps("AC-01.02")))
+	}
+
+	var zeroRegistry StepRegistry
+	zeroRegistry.Register("AC-01.01", passingAssessmentStep)
+	if len(zeroRegistry.Steps("AC-01.01")) != 1 {
+		t.Errorf("Expected zero value registry to accept steps, got %d", len(zeroRegistry.Steps("AC-01.01")))
+	}
+}
+
+func TestNewEvaluationResults(t *testing.T) {
+	tests := []struct {
+		testName       string
+		steps          map[string][]AssessmentStep
+		expectedResult Result
+	}{
+		{
+			testName:       "No registered steps",
+			steps:          map[string][]AssessmentStep{},
+			expectedResult: NeedsReview,
+		},
+		{
+			testName: "All requirements have passing steps",
+			steps: map[string][]AssessmentStep{
+				"AC-01.01": {passingAssessmentStep},
+				"AC-01.02": {passingAssessmentStep},
+			},
+			// AC-02 has no assessment requirements, so it always needs review
+			expectedResult: NeedsReview,
+		},
+		{
+			testName: "One requirement has a failing step",
+			steps: map[string][]AssessmentStep{
+				"AC-01.01": {failingAssessmentStep},
+			},
+			expectedResult: Failed,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			registry := NewStepRegistry()
+			for requirementId, steps := range test.steps {
+				registry.Register(requirementId, steps...)
+			}
+			results, err := NewEvaluationResults(testCatalog(), registry)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(results.EvaluationSet) != 2 {
+				t.Fatalf("Expected a ControlEvaluation for each control, got %d", len(results.EvaluationSet))
+			}
+			for _, assessment := range results.EvaluationSet[0].Assessments {
+				if _, registered := test.steps[assessment.RequirementId]; !registered && assessment.Result != NeedsReview {
+					t.Errorf("Expected %s without steps to need review, got %v", assessment.RequirementId, assessment.Result)
+				}
+			}
+
+			results.Evaluate(nil, append(testingApplicability, "other-applicability"), false)
+			if results.Result != test.expectedResult {
+				t.Errorf("Expected Result to be %v, but it was %v", test.expectedResult, results.Result)
+			}
+		})
+	}
+}
+
+func TestNewEvaluationResultsErrors(t *testing.T) {
+	if _, err := NewEvaluationResults(testCatalog(), nil); err == nil {
+		t.Error("Expected an error for a nil registry")
+	}
+	if _, err := NewEvaluationResults(nil, NewStepRegistry()); err == nil {
+		t.Error("Expected an error for a nil catalog")
+	}
+
+	registry := NewStepRegistry()
+	registry.Register("AC-01.01", passingAssessmentStep)
+	registry.Register("AC-01.1", passingAssessmentStep)
+	registry.Register("AC-09.01", passingAssessmentStep)
+	_, err := NewEvaluationResults(testCatalog(), registry)
+	if err == nil {
+		t.Fatal("Expected an error for steps registered for unknown requirements")
+	}
+	if !strings.Contains(err.Error(), "AC-01.1, AC-09.01") {
+		t.Errorf("Expected the error to list the unknown requirements, got %v", err)
+	}
+}
@@ -2,7 +2,11 @@ package layer4
 
 // This file is for reusable test data to help seed ideas and reduce duplication.
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/ossf/gemara/layer2"
+)
 
 var (
 	// Generic applicability for testing
@@ -231,3 +235,41 @@ func badRevertPassingAssessment() Assessment {
 		Applicability: testingApplicability,
 	}
 }
+
+func testCatalog() *layer2.Catalog {
+	return &layer2.Catalog{
+		Metadata: layer2.Metadata{
+			Id:    "test-catalog",
+			Title: "Test Catalog",
+		},
+		ControlFamilies: []layer2.ControlFamily{
+			{
+				Id:    "AC",
+				Title: "Access Control",
+				Controls: []layer2.Control{
+					{
+						Id:    "AC-01",
+						Title: "Multi-factor authentication",
+						AssessmentRequirements: []layer2.AssessmentRequirement{
+							{
+								Id:             "AC-01.01",
+								Text:           "The system MUST require multi-factor authentication.",
+								Applicability:  testingApplicability,
+								Recommendation: "Enable MFA for the organization.",
+							},
+							{
+								Id:            "AC-01.02",
+								Text:          "The system MUST reject weak second factors.",
+								Applicability: []string{"other-applicability"},
+							},
+						},
+					},
+					{
+						Id:    "AC-02",
+						Title: "Control without requirements",
+					},
+				},
+			},
+		},
+	}
+}
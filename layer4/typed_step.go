@@ -0,0 +1,84 @@
+package layer4
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/ossf/gemara/layer2"
+)
+
+// TypedAssessmentStep is an AssessmentStep whose target data has the type T, so that it can be written without
+// a type assertion on the payload.
+type TypedAssessmentStep[T any] func(payload T, c map[string]*Change) (Result, string)
+
+// Step adapts a TypedAssessmentStep so that it can be used anywhere an AssessmentStep is accepted. The step keeps
+// the name of the typed function. If the target data is not a T, the step returns Unknown instead of running.
+// Nil target data is passed to the step as nil if T is a type that can be nil, such as a pointer, map or slice,
+// and otherwise returns Unknown, so that missing target data is not evaluated as the zero value of T.
+func Step[T any](step TypedAssessmentStep[T]) AssessmentStep {
+	name := functionName(step)
+	targetType := reflect.TypeOf((*T)(nil)).Elem()
+	var nillable bool
+	switch targetType.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		nillable = true
+	}
+	return wrapStep(name, "", func(_ context.Context, payload interface{}, c map[string]*Change) (Result, string) {
+		var typed T
+		if payload == nil {
+			if !nillable {
+				return Unknown, fmt.Sprintf("step %s expected target data of type %s, but got nil", name, targetType)
+			}
+			return step(typed, c)
+		}
+		typed, ok := payload.(T)
+		if !ok {
+			return Unknown, fmt.Sprintf("step %s expected target data of type %s, but got %T", name, targetType, payload)
+		}
+		return step(typed, c)
+	})
+}
+
+// AddTypedStep adds a TypedAssessmentStep to the assessment for the requirement, in the same way as AddStep.
+func AddTypedStep[T any](c *ControlEvaluation, requirementId string, step TypedAssessmentStep[T]) error {
+	return c.AddStep(requirementId, Step(step))
+}
+
+// TypedControlEvaluation is a ControlEvaluation whose steps all expect target data of the type T.
+// Only a TypedAssessmentStep[T] can be added, and only a T can be evaluated, so the compiler checks that the
+// target data matches the steps.
+type TypedControlEvaluation[T any] struct {
+	evaluation *ControlEvaluation
+}
+
+// NewTypedControlEvaluation creates a TypedControlEvaluation for the control with the provided ID in a Layer 2
+// Catalog, in the same way as NewControlEvaluation.
+func NewTypedControlEvaluation[T any](catalog *layer2.Catalog, controlId string) (*TypedControlEvaluation[T], error) {
+	evaluation, err := NewControlEvaluation(catalog, controlId)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedControlEvaluation[T]{evaluation: evaluation}, nil
+}
+
+// AddStep queues a new step in the Assessment for the requirement with the provided ID.
+func (t *TypedControlEvaluation[T]) AddStep(requirementId string, step TypedAssessmentStep[T]) error {
+	return AddTypedStep(t.evaluation, requirementId, step)
+}
+
+// Evaluate runs each step in each assessment against the targetData, in the same way as ControlEvaluation.Evaluate.
+func (t *TypedControlEvaluation[T]) Evaluate(targetData T, userApplicability []string, changesAllowed bool) {
+	t.evaluation.Evaluate(targetData, userApplicability, changesAllowed)
+}
+
+// EvaluateContext runs each assessment against the targetData, in the same way as ControlEvaluation.EvaluateContext.
+func (t *TypedControlEvaluation[T]) EvaluateContext(ctx context.Context, targetData T, userApplicability []string, changesAllowed bool, opts ...RunOption) {
+	t.evaluation.EvaluateContext(ctx, targetData, userApplicability, changesAllowed, opts...)
+}
+
+// ControlEvaluation returns the underlying ControlEvaluation, which holds the results and may be added to
+// EvaluationResults.
+func (t *TypedControlEvaluation[T]) ControlEvaluation() *ControlEvaluation {
+	return t.evaluation
+}
@@ -0,0 +1,113 @@
+package layer4
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type typedTarget struct {
+	Enabled bool
+}
+
+func typedTargetStep(payload *typedTarget, c map[string]*Change) (Result, string) {
+	if payload == nil {
+		return NeedsReview, "no target"
+	}
+	if payload.Enabled {
+		return Passed, ""
+	}
+	return Failed, "not enabled"
+}
+
+func TestStep(t *testing.T) {
+	tests := []struct {
+		testName        string
+		targetData      interface{}
+		expectedResult  Result
+		expectedMessage string
+	}{
+		{
+			testName:       "Matching target data",
+			targetData:     &typedTarget{Enabled: true},
+			expectedResult: Passed,
+		},
+		{
+			testName:        "Nil target data",
+			targetData:      nil,
+			expectedResult:  NeedsReview,
+			expectedMessage: "no target",
+		},
+		{
+			testName:        "Wrong target data type",
+			targetData:      "not a target",
+			expectedResult:  Unknown,
+			expectedMessage: "expected target data of type *layer4.typedTarget, but got string",
+		},
+	}
+	step := Step(typedTargetStep)
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			result, message := callStep(context.Background(), step, test.targetData, nil)
+			if result != test.expectedResult {
+				t.Errorf("Expected result %s, got %s", test.expectedResult, result)
+			}
+			if !strings.Contains(message, test.expectedMessage) {
+				t.Errorf("Expected message to contain %q, got %q", test.expectedMessage, message)
+			}
+		})
+	}
+	if step.String() != "github.com/ossf/gemara/layer4.typedTargetStep" {
+		t.Errorf("Expected the name of the typed function, got %s", step.String())
+	}
+}
+
+func TestStepNilValueTarget(t *testing.T) {
+	step := Step(func(payload typedTarget, c map[string]*Change) (Result, string) {
+		return Passed, ""
+	})
+	result, message := callStep(context.Background(), step, nil, nil)
+	if result != Unknown || !strings.Contains(message, "but got nil") {
+		t.Errorf("Expected nil target data to return Unknown for a value type, got %s: %s", result, message)
+	}
+
+	nillableSteps := map[string]AssessmentStep{
+		"map": Step(func(payload map[string]string, c map[string]*Change) (Result, string) {
+			return Passed, ""
+		}),
+		"slice": Step(func(payload []string, c map[string]*Change) (Result, string) {
+			return Passed, ""
+		}),
+		"func": Step(func(payload func() bool, c map[string]*Change) (Result, string) {
+			return Passed, ""
+		}),
+		"chan": Step(func(payload chan string, c map[string]*Change) (Result, string) {
+			return Passed, ""
+		}),
+	}
+	for kind, step := range nillableSteps {
+		if result, message := callStep(context.Background(), step, nil, nil); result != Passed {
+			t.Errorf("Expected nil target data to be passed to a step expecting a %s, got %s: %s", kind, result, message)
+		}
+	}
+}
+
+func TestTypedControlEvaluation(t *testing.T) {
+	c, err := NewTypedControlEvaluation[*typedTarget](testCatalog(), "AC-01")
+	if err != nil {
+		t.Fatalf("Unexpected error creating evaluation: %v", err)
+	}
+	if err := c.AddStep("AC-01.01", typedTargetStep); err != nil {
+		t.Fatalf("Unexpected error adding step: %v", err)
+	}
+	if err := c.AddStep("AC-99.01", typedTargetStep); err == nil {
+		t.Errorf("Expected an error adding a step for an unknown requirement")
+	}
+
+	c.EvaluateContext(context.Background(), &typedTarget{Enabled: false}, testingApplicability, false)
+
+	evaluation := c.ControlEvaluation()
+	if evaluation.Result != Failed || evaluation.Message != "not enabled" {
+		t.Errorf("Expected a Failed result from the typed step, got %s: %s", evaluation.Result, evaluation.Message)
+	}
+}
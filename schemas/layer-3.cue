@@ -103,10 +103,11 @@ import "time"
 	recommendations?: [...string]
 	"base-guideline-id"?: string @go(BaseGuidelineID) @yaml("base-guideline-id,omitempty")
 	rationale?:           string @go(Rationale,optional=nillable)
-	"guideline-mappings"?: [...#Mapping] @go(GuidelineMappings) @yaml("guideline-mappings,omitempty")
-	"principle-mappings"?: [...#Mapping] @go(PrincipleMappings) @yaml("principle-mappings,omitempty")
+	"guideline-mappings"?: [...#GuidelineMapping] @go(GuidelineMappings) @yaml("guideline-mappings,omitempty")
+	"principle-mappings"?: [...#GuidelineMapping] @go(PrincipleMappings) @yaml("principle-mappings,omitempty")
 	"see-also"?: [...string] @go(SeeAlso) @yaml("see-also,omitempty")
 	"external-references"?: [...string] @go(ExternalReferences) @yaml("external-references,omitempty")
+	"part-modifications"?: [...#PartModifier] @go(PartModifications) @yaml("part-modifications,omitempty")
 }
 
 #PartModifier: {
@@ -119,6 +120,19 @@ import "time"
 	recommendations?: [...string]
 }
 
+// Guideline mappings are the same as Layer 1 mappings, used to replace the mappings of a modified guideline
+#GuidelineMapping: {
+	"reference-id": string @go(ReferenceId)
+	entries: [...#MappingEntry]
+	remarks?: string
+}
+
+#MappingEntry: {
+	"reference-id": string @go(ReferenceId)
+	strength:       int & >=1 & <=10
+	remarks?:       string
+}
+
 #Contact: {
 	// The contact person's name.
 	name: string
@@ -3,6 +3,8 @@ package schemas
 import "time"
 
 #EvaluationResults: {
+	result?:            #Result
+	"corrupted-state"?: bool @go(CorruptedState)
 	"evaluation-set": [#ControlEvaluation, ...#ControlEvaluation] @go(EvaluationSet)
 	...
 }
@@ -24,15 +26,37 @@ import "time"
 	message:     string
 	steps: [...#AssessmentStep]
 	"steps-executed"?: int @go(StepsExecuted)
+	"step-results"?: [...#StepResult] @go(StepResults)
 	"start":           #Datetime
 	"end"?:            #Datetime
 	value?:            _
+	evidence?: [...#Evidence]
 	changes?: {[string]: #Change}
 	recommendation?: string
 }
 
 #AssessmentStep: string
 
+#StepResult: {
+	name:     string
+	result:   #Result
+	message:  string
+	start:    #Datetime
+	end?:     #Datetime
+	attempt?: int & >=1
+}
+
+#Evidence: {
+	name:         string
+	"media-type": string @go(MediaType)
+	content?:     string
+	encoding?:    "base64"
+	reference?:   string
+	sha256:       string & =~"^[a-f0-9]{64}$" @go(SHA256)
+	collected:    #Datetime
+	step?:        string
+}
+
 #Change: {
 	"target-name":    string @go(TargetName)
 	description:      string
@@ -40,6 +64,9 @@ import "time"
 	applied?:         bool
 	reverted?:        bool
 	error?:           string
+	allowed?:         bool
+	planned?:         bool
+	"planned-input"?: _ @go(PlannedInput)
 }
 
 #Result: "Not Run" | "Passed" | "Failed" | "Needs Review" | "Not Applicable" | "Unknown"